@@ -18,15 +18,18 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
-	"strings"
 
+	"github.com/ibm-messaging/mq-container/internal/display"
+	"github.com/ibm-messaging/mq-container/internal/fdc"
+	"github.com/ibm-messaging/mq-container/internal/logger"
 	"github.com/ibm-messaging/mq-container/internal/name"
 )
 
 func queueManagerHealthy() (bool, error) {
-	name, err := name.GetQueueManagerName()
+	name, err := name.ReadFile(name.File)
 	if err != nil {
 		return false, err
 	}
@@ -37,19 +40,28 @@ func queueManagerHealthy() (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	if !strings.Contains(string(out), "(RUNNING)") {
-		return false, nil
-	}
-	return true, nil
+	return display.ParseAttributes(string(out))["STATUS"] == "RUNNING", nil
 }
 
 func main() {
+	log, err := logger.NewLoggerFromEnv()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 	healthy, err := queueManagerHealthy()
 	if err != nil {
+		log.Errorf("Error checking queue manager status: %v", err)
 		os.Exit(2)
 	}
 	if !healthy {
+		log.Error("Queue manager is not running")
 		os.Exit(1)
 	}
+	// FDCs don't make the queue manager unhealthy, but they should be investigated
+	count, err := fdc.ReadCount(fdc.CountFile)
+	if err == nil && count > 0 {
+		log.Printf("%v FDC reports detected since the queue manager was started", count)
+	}
 	os.Exit(0)
 }
@@ -14,18 +14,35 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// chkmqready checks that MQ is ready for work, by checking if the MQ listener port is available
+// chkmqready checks that MQ is ready for work, by checking if each of the MQ listener ports is available
 package main
 
 import (
+	"fmt"
 	"net"
 	"os"
+
+	"github.com/ibm-messaging/mq-container/internal/listener"
+	"github.com/ibm-messaging/mq-container/internal/logger"
 )
 
 func main() {
-	conn, err := net.Dial("tcp", "127.0.0.1:1414")
+	log, err := logger.NewLoggerFromEnv()
 	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	conn.Close()
+	listeners, err := listener.Configured()
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	for _, l := range listeners {
+		conn, err := net.Dial("tcp", l.ProbeAddress())
+		if err != nil {
+			log.Errorf("Listener %v not available: %v", l, err)
+			os.Exit(1)
+		}
+		conn.Close()
+	}
 }
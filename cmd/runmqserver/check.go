@@ -0,0 +1,150 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ibm-messaging/mq-container/internal/migrate"
+	"github.com/ibm-messaging/mq-container/internal/mqsc"
+	"github.com/ibm-messaging/mq-container/internal/name"
+	"github.com/ibm-messaging/mq-container/internal/qmconfig"
+)
+
+// lintScript checks the syntax of an MQSC script, and writes a diagnostic
+// for each problem found.  The number of problems is returned.
+func lintScript(w io.Writer, s *mqscScript) int {
+	diags := mqsc.Lint(s.text)
+	for _, d := range diags {
+		fmt.Fprintf(w, "%v: %v\n", s.location(d.Line), d.Message)
+	}
+	return len(diags)
+}
+
+// checkFiles checks the declarative configuration, migrations, qm.ini
+// fragments and MQSC files in a directory, in the same way as they would be applied to the queue
+// manager, using the overlay for each of the environments.  A diagnostic is
+// written for each problem found, and the number of problems is returned.
+func checkFiles(w io.Writer, dir string, envs []string, data *mqscData) (int, error) {
+	problems := 0
+	path := qmconfig.Find(declarativeFiles(dir))
+	if path != "" {
+		c, err := qmconfig.Load(path)
+		switch e := err.(type) {
+		case nil:
+			problems += lintScript(w, newMQSCScript(path+" (generated MQSC)", c.MQSC()))
+		case qmconfig.ValidationErrors:
+			for _, v := range e {
+				fmt.Fprintf(w, "%v: %v\n", path, v)
+			}
+			problems += len(e)
+		default:
+			fmt.Fprintf(w, "%v: %v\n", path, err)
+			problems++
+		}
+	}
+	_, err := qmINIOverrides(qmINIDir(dir), nil)
+	if err != nil {
+		fmt.Fprintln(w, err)
+		problems++
+	}
+	scripts := make([]*mqscScript, 0)
+	migrations, err := migrate.Find(migrationsDir(dir))
+	if err != nil {
+		fmt.Fprintf(w, "%v: %v\n", migrationsDir(dir), err)
+		problems++
+	}
+	for _, m := range migrations {
+		buf, err := ioutil.ReadFile(m.Path)
+		if err != nil {
+			return problems, err
+		}
+		text, err := renderMQSC(m.Path, string(buf), data)
+		if err != nil {
+			fmt.Fprintf(w, "%v: %v\n", m.Path, err)
+			problems++
+			continue
+		}
+		scripts = append(scripts, newMQSCScript(m.Path, text))
+	}
+	files, err := layoutFiles(dir, envs)
+	if err != nil {
+		return problems, err
+	}
+	included := make(map[string]bool)
+	for _, file := range files {
+		s, err := loadMQSCScript(file, data, nil)
+		if err != nil {
+			fmt.Fprintf(w, "%v: %v\n", file, err)
+			problems++
+			continue
+		}
+		for _, inc := range s.includes {
+			included[filepath.Clean(inc)] = true
+		}
+		scripts = append(scripts, s)
+	}
+	for _, s := range scripts {
+		// Included files are checked as part of the files which include them
+		if !included[filepath.Clean(s.path)] {
+			problems += lintScript(w, s)
+		}
+	}
+	return problems, nil
+}
+
+// checkConfig checks the configuration which would be applied to the queue
+// manager, without needing an MQ installation.  The directory can be
+// specified as an argument, otherwise /etc/mqm is used.  Templates are
+// rendered using the current environment.  If MQ_ENV isn't set, then the
+// overlays for all environments are checked.
+func checkConfig(args []string) error {
+	dir := configDir
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	qmgr, err := name.GetQueueManagerName()
+	if err != nil {
+		return err
+	}
+	data, err := newMQSCData(qmgr)
+	if err != nil {
+		return err
+	}
+	// Check the overlay for every environment, unless MQ_ENV is set
+	var envs []string
+	if env := os.Getenv("MQ_ENV"); env != "" {
+		envs = []string{env}
+	} else {
+		envs, err = listOverlays(dir)
+		if err != nil {
+			return err
+		}
+	}
+	problems, err := checkFiles(os.Stdout, dir, envs, data)
+	if err != nil {
+		return err
+	}
+	if problems > 0 {
+		return fmt.Errorf("%v problems found in the configuration in %v", problems, dir)
+	}
+	fmt.Printf("No problems found in the configuration in %v\n", dir)
+	return nil
+}
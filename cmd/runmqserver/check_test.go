@@ -0,0 +1,75 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var checkFilesTests = []struct {
+	files    map[string]string
+	problems int
+	out      string
+}{
+	{map[string]string{"a.mqsc": "DEFINE QLOCAL(A)\n", "b.txt": "NOT MQSC"}, 0, ""},
+	{map[string]string{"a.mqsc": "* Comment\nDEFINE QLOCAL(A) MAXDEPT(5)\n"}, 1, "DIR/a.mqsc:2: unknown parameter MAXDEPT\n"},
+	{map[string]string{"a.mqsc": "DEFINE QLOCAL({{ .QueueManager }}.IN)\nDEFNE QLOCAL(B)\n"}, 1, "DIR/a.mqsc:2: unknown command DEFNE\n"},
+	{map[string]string{"a.mqsc": "DEFINE QLOCAL({{ required \"TEST_CHECK_MISSING\" }})\n"}, 1, "DIR/a.mqsc: Error rendering MQSC template: template: DIR/a.mqsc:1:17: executing \"DIR/a.mqsc\" at <required \"TEST_CHECK_MISSING\">: error calling required: required environment variable TEST_CHECK_MISSING is not set\n"},
+	{map[string]string{"qmgr.yaml": "queues:\n  - name: A\n"}, 0, ""},
+	{map[string]string{"migrations/V001__a.mqsc": "DELETE QLOCAL(A)\n", "migrations/V002__b.mqsc": "DELET QLOCAL(B)\n"}, 1, "DIR/migrations/V002__b.mqsc:1: unknown command DELET\n"},
+	{map[string]string{"migrations/a.mqsc": "DELETE QLOCAL(A)\n"}, 1, "DIR/migrations: Invalid migration file name a.mqsc: expected a name such as V001__create_queues.mqsc\n"},
+	{map[string]string{"qmgr.yaml": "queues:\n  - name: A\n    maxDepth: x\n"}, 1, "DIR/qmgr.yaml: queues[0].maxDepth: must be an integer\n"},
+	{map[string]string{"qm.ini.d/log.ini": "Log:\n   LogBufferPages=512\n"}, 0, ""},
+	{map[string]string{"qm.ini.d/bad.ini": "Logs:\n   LogBufferPages=512\n"}, 1, "DIR/qm.ini.d/bad.ini: Invalid qm.ini stanza Logs\n"},
+}
+
+func TestCheckFiles(t *testing.T) {
+	data := &mqscData{QueueManager: "QM1"}
+	for _, table := range checkFilesTests {
+		dir, err := ioutil.TempDir("", "check")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		err = os.Mkdir(filepath.Join(dir, "migrations"), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range table.files {
+			err = os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		var buf bytes.Buffer
+		problems, err := checkFiles(&buf, dir, nil, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := bytes.Replace(buf.Bytes(), []byte(dir), []byte("DIR"), -1)
+		if problems != table.problems || string(out) != table.out {
+			t.Errorf("checkFiles(%v) - expected %v problems and %q, got %v and %q", table.files, table.problems, table.out, problems, out)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/internal/mqini"
+)
+
+// maxLogFiles is the maximum total number of primary and secondary log files
+const maxLogFiles = 511
+
+var objectNamePattern = regexp.MustCompile(`^[A-Za-z0-9._/%]{1,48}$`)
+
+// createOptions are the options passed to crtmqm.  Zero values mean that the
+// crtmqm default is used.
+type createOptions struct {
+	logType           string
+	logFilePages      int
+	logPrimaryFiles   int
+	logSecondaryFiles int
+	deadLetterQueue   string
+	description       string
+	maxHandles        int
+	maxUncommitted    int
+}
+
+// getEnvRange returns the value of an environment variable as an integer in
+// the specified range, or zero if the variable isn't set
+func getEnvRange(name string, min int, max int) (int, error) {
+	s, ok := os.LookupEnv(name)
+	if !ok || s == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil || i < min || i > max {
+		return 0, fmt.Errorf("Invalid value for %v: %v: must be between %v and %v", name, s, min, max)
+	}
+	return i, nil
+}
+
+// createOptionsFromEnv returns the crtmqm options set in the environment
+func createOptionsFromEnv() (*createOptions, error) {
+	o := &createOptions{}
+	switch t := strings.ToLower(os.Getenv("MQ_LOG_TYPE")); t {
+	case "":
+	case "circular", "linear":
+		o.logType = strings.ToUpper(t)
+	default:
+		return nil, fmt.Errorf("Invalid value for MQ_LOG_TYPE: %v: must be circular or linear", t)
+	}
+	var err error
+	o.logFilePages, err = getEnvRange("MQ_LOG_FILE_PAGES", 64, 65535)
+	if err != nil {
+		return nil, err
+	}
+	o.logPrimaryFiles, err = getEnvRange("MQ_LOG_PRIMARY_FILES", 2, 510)
+	if err != nil {
+		return nil, err
+	}
+	o.logSecondaryFiles, err = getEnvRange("MQ_LOG_SECONDARY_FILES", 1, 509)
+	if err != nil {
+		return nil, err
+	}
+	if o.logPrimaryFiles+o.logSecondaryFiles > maxLogFiles {
+		return nil, fmt.Errorf("MQ_LOG_PRIMARY_FILES and MQ_LOG_SECONDARY_FILES must not add up to more than %v", maxLogFiles)
+	}
+	o.deadLetterQueue = os.Getenv("MQ_DEAD_LETTER_QUEUE")
+	if o.deadLetterQueue != "" && !objectNamePattern.MatchString(o.deadLetterQueue) {
+		return nil, fmt.Errorf("Invalid value for MQ_DEAD_LETTER_QUEUE: %v: must be a valid queue name", o.deadLetterQueue)
+	}
+	o.description = os.Getenv("MQ_QMGR_DESCRIPTION")
+	if len(o.description) > 64 {
+		return nil, fmt.Errorf("MQ_QMGR_DESCRIPTION must not be longer than 64 characters")
+	}
+	o.maxHandles, err = getEnvRange("MQ_MAX_HANDLES", 1, 999999999)
+	if err != nil {
+		return nil, err
+	}
+	o.maxUncommitted, err = getEnvRange("MQ_MAX_UNCOMMITTED_MESSAGES", 1, 999999999)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// args returns the arguments to pass to crtmqm for the options
+func (o *createOptions) args() []string {
+	args := make([]string, 0)
+	switch o.logType {
+	case "CIRCULAR":
+		args = append(args, "-lc")
+	case "LINEAR":
+		args = append(args, "-ll")
+	}
+	ints := []struct {
+		flag  string
+		value int
+	}{
+		{"-lf", o.logFilePages},
+		{"-lp", o.logPrimaryFiles},
+		{"-ls", o.logSecondaryFiles},
+		{"-h", o.maxHandles},
+		{"-x", o.maxUncommitted},
+	}
+	for _, i := range ints {
+		if i.value > 0 {
+			args = append(args, i.flag, strconv.Itoa(i.value))
+		}
+	}
+	if o.deadLetterQueue != "" {
+		args = append(args, "-u", o.deadLetterQueue)
+	}
+	if o.description != "" {
+		args = append(args, "-c", o.description)
+	}
+	return args
+}
+
+// mismatches compares the log options with the Log stanza in an existing
+// queue manager's qm.ini, and returns a message for each difference.  The
+// log type and log file size can't be changed once the queue manager has
+// been created.
+func (o *createOptions) mismatches(qmINI *mqini.File) []string {
+	problems := make([]string, 0)
+	stanza := qmINI.Stanza("Log")
+	if stanza == nil {
+		return problems
+	}
+	options := []struct {
+		env   string
+		key   string
+		value string
+	}{
+		{"MQ_LOG_TYPE", "LogType", o.logType},
+		{"MQ_LOG_FILE_PAGES", "LogFilePages", strconv.Itoa(o.logFilePages)},
+		{"MQ_LOG_PRIMARY_FILES", "LogPrimaryFiles", strconv.Itoa(o.logPrimaryFiles)},
+		{"MQ_LOG_SECONDARY_FILES", "LogSecondaryFiles", strconv.Itoa(o.logSecondaryFiles)},
+	}
+	for _, opt := range options {
+		if opt.value == "" || opt.value == "0" {
+			continue
+		}
+		v, ok := stanza.Get(opt.key)
+		if ok && !strings.EqualFold(v, opt.value) {
+			problems = append(problems, fmt.Sprintf("%v is %v, but the existing queue manager has %v=%v", opt.env, opt.value, opt.key, v))
+		}
+	}
+	return problems
+}
+
+// checkExistingQueueManager warns if an existing queue manager was created
+// with different log options
+func checkExistingQueueManager(name string, o *createOptions) {
+	f, err := mqini.Load(qmINIPath(name))
+	if err != nil {
+		log.Printf("Unable to check the options used to create queue manager %v: %v", name, err)
+		return
+	}
+	for _, m := range o.mismatches(f) {
+		log.Printf("Warning: %v.  The crtmqm options are only used when the queue manager is created.", m)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ibm-messaging/mq-container/internal/mqini"
+)
+
+var createOptionsTests = []struct {
+	env  map[string]string
+	args []string
+	err  bool
+}{
+	{map[string]string{}, []string{}, false},
+	{
+		map[string]string{"MQ_LOG_TYPE": "Linear", "MQ_LOG_FILE_PAGES": "16384", "MQ_LOG_PRIMARY_FILES": "10", "MQ_LOG_SECONDARY_FILES": "20"},
+		[]string{"-ll", "-lf", "16384", "-lp", "10", "-ls", "20"},
+		false,
+	},
+	{
+		map[string]string{"MQ_LOG_TYPE": "circular", "MQ_DEAD_LETTER_QUEUE": "SYSTEM.DEAD.LETTER.QUEUE", "MQ_QMGR_DESCRIPTION": "Test queue manager", "MQ_MAX_HANDLES": "1000", "MQ_MAX_UNCOMMITTED_MESSAGES": "20000"},
+		[]string{"-lc", "-h", "1000", "-x", "20000", "-u", "SYSTEM.DEAD.LETTER.QUEUE", "-c", "Test queue manager"},
+		false,
+	},
+	{map[string]string{"MQ_LOG_TYPE": "round"}, nil, true},
+	{map[string]string{"MQ_LOG_FILE_PAGES": "32"}, nil, true},
+	{map[string]string{"MQ_LOG_PRIMARY_FILES": "x"}, nil, true},
+	{map[string]string{"MQ_LOG_PRIMARY_FILES": "300", "MQ_LOG_SECONDARY_FILES": "300"}, nil, true},
+	{map[string]string{"MQ_DEAD_LETTER_QUEUE": "DLQ*"}, nil, true},
+	{map[string]string{"MQ_QMGR_DESCRIPTION": strings.Repeat("x", 65)}, nil, true},
+	{map[string]string{"MQ_MAX_HANDLES": "0"}, nil, true},
+}
+
+func TestCreateOptions(t *testing.T) {
+	for _, table := range createOptionsTests {
+		for k, v := range table.env {
+			os.Setenv(k, v)
+		}
+		o, err := createOptionsFromEnv()
+		for k := range table.env {
+			os.Unsetenv(k)
+		}
+		if table.err {
+			if err == nil {
+				t.Errorf("createOptionsFromEnv(%v) - expected error", table.env)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("createOptionsFromEnv(%v) - unexpected error: %v", table.env, err)
+			continue
+		}
+		if !reflect.DeepEqual(o.args(), table.args) {
+			t.Errorf("args(%v) - expected %v, got %v", table.env, table.args, o.args())
+		}
+	}
+}
+
+func TestCreateOptionsMismatches(t *testing.T) {
+	f, err := mqini.Parse([]byte("Log:\n   LogPrimaryFiles=3\n   LogSecondaryFiles=2\n   LogFilePages=4096\n   LogType=CIRCULAR\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &createOptions{logType: "LINEAR", logFilePages: 4096, logPrimaryFiles: 10}
+	expected := []string{
+		"MQ_LOG_TYPE is LINEAR, but the existing queue manager has LogType=CIRCULAR",
+		"MQ_LOG_PRIMARY_FILES is 10, but the existing queue manager has LogPrimaryFiles=3",
+	}
+	m := o.mismatches(f)
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("mismatches() - expected %v, got %v", expected, m)
+	}
+}
@@ -16,7 +16,6 @@ limitations under the License.
 package main
 
 import (
-	"log"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -49,7 +48,7 @@ func createVolume(path string) error {
 		if stat.Uid != mqmUID || stat.Gid != mqmGID {
 			err = os.Chown(dataPath, int(mqmUID), int(mqmGID))
 			if err != nil {
-				log.Printf("Error: Unable to change ownership of %v", dataPath)
+				log.Errorf("Unable to change ownership of %v", dataPath)
 				return err
 			}
 		}
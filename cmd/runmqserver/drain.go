@@ -0,0 +1,127 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/display"
+)
+
+// runMQSC runs the specified MQSC commands against the queue manager, without
+// echoing the commands in the output
+func runMQSC(qmgr string, mqsc string) (string, error) {
+	return display.RunMQSC(qmgr)(mqsc)
+}
+
+// stopListeners stops all the running listeners, so that no new connections
+// can be made to the queue manager
+func stopListeners(qmgr string) error {
+	listeners, err := display.Display(display.RunMQSC(qmgr), "DISPLAY LSSTATUS(*)")
+	if err != nil {
+		return fmt.Errorf("Error displaying listener status: %v", err)
+	}
+	for _, l := range display.Values(listeners, "LISTENER") {
+		log.Printf("Stopping listener %v", l)
+		out, err := runMQSC(qmgr, fmt.Sprintf("STOP LISTENER('%v')", l))
+		if err != nil {
+			log.Errorf("Error stopping listener %v: %v", l, strings.TrimSpace(out))
+		}
+	}
+	return nil
+}
+
+// stopServerConnChannels stops all the active server-connection channels in
+// quiesce mode, which asks client applications to disconnect
+func stopServerConnChannels(qmgr string) error {
+	channels, err := display.Display(display.RunMQSC(qmgr), "DISPLAY CHSTATUS(*) WHERE(CHLTYPE EQ SVRCONN)")
+	if err != nil {
+		return fmt.Errorf("Error displaying channel status: %v", err)
+	}
+	for _, c := range display.Values(channels, "CHANNEL") {
+		log.Printf("Stopping channel %v", c)
+		out, err := runMQSC(qmgr, fmt.Sprintf("STOP CHANNEL('%v') MODE(QUIESCE)", c))
+		if err != nil {
+			log.Errorf("Error stopping channel %v: %v", c, strings.TrimSpace(out))
+		}
+	}
+	return nil
+}
+
+// drainInterval is how often the application connections are checked while
+// draining
+var drainInterval = time.Second
+
+// runmqscTag is the application tag of runmqsc
+const runmqscTag string = "runmqsc"
+
+// countApplicationConnections returns the number of connections to the
+// queue manager from user applications.  The connection made by runmqsc to
+// run the DISPLAY command is also a user application, so isn't counted.
+func countApplicationConnections(run display.Runner) (int, error) {
+	conns, err := display.Display(run, "DISPLAY CONN(*) TYPE(CONN) WHERE(APPLTYPE EQ USER)")
+	if err != nil {
+		return 0, fmt.Errorf("Error displaying connections: %v", err)
+	}
+	apps := make([]*display.Object, 0, len(conns))
+	for _, c := range conns {
+		if !strings.HasSuffix(c.Get("APPLTAG"), runmqscTag) {
+			apps = append(apps, c)
+		}
+	}
+	return len(display.Values(apps, "CONN")), nil
+}
+
+// waitForConnections waits for application connections to end, until the
+// timeout since the drain started
+func waitForConnections(run display.Runner, start time.Time, timeout time.Duration) {
+	for {
+		n, err := countApplicationConnections(run)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		if n == 0 {
+			log.Printf("Drained queue manager in %v", time.Since(start))
+			return
+		}
+		if time.Since(start) >= timeout {
+			log.Printf("Timed out draining queue manager, with %v application connections remaining", n)
+			return
+		}
+		log.Debugf("Waiting for %v application connections to end", n)
+		time.Sleep(drainInterval)
+	}
+}
+
+// drainQueueManager stops new connections from being made to the queue
+// manager, asks client applications to disconnect, and waits for existing
+// application connections to end, for up to the specified timeout
+func drainQueueManager(qmgr string, timeout time.Duration) {
+	log.Printf("Draining queue manager for up to %v", timeout)
+	start := time.Now()
+	err := stopListeners(qmgr)
+	if err != nil {
+		log.Error(err)
+	}
+	err = stopServerConnChannels(qmgr)
+	if err != nil {
+		log.Error(err)
+	}
+	waitForConnections(display.RunMQSC(qmgr), start, timeout)
+}
@@ -0,0 +1,82 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/logger"
+)
+
+// runmqscConn is the connection made by runmqsc itself to display the
+// connections
+const runmqscConn = `AMQ8276I: Display Connection details.
+   CONN(5A4B2C1D00030001)
+   EXTCONN(414D5143514D31202020202020202020)
+   TYPE(CONN)
+   APPLTAG(runmqsc)                         APPLTYPE(USER)
+`
+
+func TestCountApplicationConnections(t *testing.T) {
+	buf, err := ioutil.ReadFile(filepath.Join("..", "..", "internal", "display", "testdata", "conn.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(mqsc string) (string, error) {
+		return string(buf), nil
+	}
+	n, err := countApplicationConnections(run)
+	if err != nil || n != 2 {
+		t.Errorf("countApplicationConnections() - expected 2, got %v, %v", n, err)
+	}
+}
+
+func TestWaitForConnections(t *testing.T) {
+	var out bytes.Buffer
+	log, _ = logger.NewLogger(&out, false, logger.FormatBasic, "test")
+	buf, err := ioutil.ReadFile(filepath.Join("..", "..", "internal", "display", "testdata", "conn.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(d time.Duration) { drainInterval = d }(drainInterval)
+	drainInterval = 10 * time.Millisecond
+	calls := 0
+	// The applications disconnect after the first check, leaving only the
+	// runmqsc connection
+	run := func(mqsc string) (string, error) {
+		calls++
+		if calls == 1 {
+			return string(buf), nil
+		}
+		return runmqscConn, nil
+	}
+	start := time.Now()
+	waitForConnections(run, start, 10*time.Second)
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("waitForConnections() - expected the drain to end once only runmqsc was connected, took %v", time.Since(start))
+	}
+	if !strings.Contains(out.String(), "Drained queue manager") {
+		t.Errorf("waitForConnections() - expected the drain to complete, got %q", out.String())
+	}
+	if calls != 2 {
+		t.Errorf("waitForConnections() - expected 2 checks, got %v", calls)
+	}
+}
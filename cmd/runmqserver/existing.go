@@ -0,0 +1,135 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/internal/mqini"
+	"github.com/ibm-messaging/mq-container/internal/name"
+)
+
+// queueManagerName reverses the transformation made by queueManagerDataDir
+func queueManagerName(dir string) string {
+	name := strings.Replace(dir, "!", ".", -1)
+	return strings.Replace(name, "&", "/", -1)
+}
+
+// existingQueueManagers returns the names of the queue managers which already
+// exist, from the QueueManager stanzas in mqs.ini, and from any directories
+// containing a qm.ini file which mqs.ini doesn't refer to
+func existingQueueManagers(mqsINI string, qmgrsDir string) ([]string, error) {
+	names := make(map[string]bool)
+	dirs := make(map[string]bool)
+	f, err := mqini.Load(mqsINI)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		for _, s := range f.Stanzas("QueueManager") {
+			if name, ok := s.Get("Name"); ok {
+				names[name] = true
+			}
+			if dir, ok := s.Get("Directory"); ok {
+				dirs[dir] = true
+			}
+		}
+	}
+	files, err := ioutil.ReadDir(qmgrsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, file := range files {
+		if !file.IsDir() || dirs[file.Name()] {
+			continue
+		}
+		_, err := os.Stat(filepath.Join(qmgrsDir, file.Name(), "qm.ini"))
+		if err == nil {
+			names[queueManagerName(file.Name())] = true
+		}
+	}
+	existing := make([]string, 0, len(names))
+	for name := range names {
+		existing = append(existing, name)
+	}
+	sort.Strings(existing)
+	return existing, nil
+}
+
+// resolveExistingQueueManager checks the queue manager name against the
+// queue managers which already exist on the volume.  If the name doesn't
+// match, then an error is returned, unless adopt is true and there is only
+// one existing queue manager, in which case its name is returned instead.
+// If legacy isn't empty, then it is the name which an earlier version would
+// have used, and the queue manager with that name is used if it exists.
+func resolveExistingQueueManager(name string, legacy string, existing []string, adopt bool) (string, error) {
+	if len(existing) == 0 {
+		return name, nil
+	}
+	for _, e := range existing {
+		if e == name {
+			return name, nil
+		}
+	}
+	for _, e := range existing {
+		if legacy != "" && e == legacy {
+			log.Printf("Using existing queue manager %v, which was named from the host name by an earlier version, instead of %v", legacy, name)
+			return legacy, nil
+		}
+	}
+	list := strings.Join(existing, ", ")
+	if !adopt {
+		return "", fmt.Errorf("Queue manager %v doesn't exist, but the volume already contains queue manager %v.  Set MQ_QMGR_NAME to use an existing queue manager, or set MQ_ADOPT_EXISTING_QMGR=true to use it whatever the host name", name, list)
+	}
+	if len(existing) > 1 {
+		return "", fmt.Errorf("Queue manager %v doesn't exist, and MQ_ADOPT_EXISTING_QMGR can't be used because the volume contains more than one queue manager: %v", name, list)
+	}
+	log.Printf("Adopting existing queue manager %v, instead of %v", existing[0], name)
+	return existing[0], nil
+}
+
+// checkExistingQueueManagers returns the name of the queue manager to use,
+// taking into account the queue managers already on the volume.  The name is
+// recorded for chkmqhealthy.
+func checkExistingQueueManagers(qmgr string) (string, error) {
+	existing, err := existingQueueManagers("/var/mqm/mqs.ini", "/var/mqm/qmgrs")
+	if err != nil {
+		return "", fmt.Errorf("Unable to list the existing queue managers: %v", err)
+	}
+	// Earlier versions named the queue manager differently from the host name
+	legacy := ""
+	if os.Getenv("MQ_QMGR_NAME") == "" {
+		hostname, err := os.Hostname()
+		if err == nil {
+			legacy = name.LegacyFromHostname(hostname)
+		}
+	}
+	adopt := strings.ToLower(os.Getenv("MQ_ADOPT_EXISTING_QMGR"))
+	qmgr, err = resolveExistingQueueManager(qmgr, legacy, existing, adopt == "true" || adopt == "1")
+	if err != nil {
+		return "", err
+	}
+	err = name.WriteFile(name.File, qmgr)
+	if err != nil {
+		return "", fmt.Errorf("Unable to record the queue manager name: %v", err)
+	}
+	return qmgr, nil
+}
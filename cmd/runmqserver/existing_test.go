@@ -0,0 +1,115 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ibm-messaging/mq-container/internal/logger"
+)
+
+const testMQSINI = `AllQueueManagers:
+   DefaultPrefix=/var/mqm
+QueueManager:
+   Name=QM1
+   Prefix=/var/mqm
+   Directory=QM1
+QueueManager:
+   Name=APP.QM
+   Prefix=/var/mqm
+   Directory=APP!QM
+`
+
+func TestExistingQueueManagers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "existing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	qmgrs := filepath.Join(dir, "qmgrs")
+	mqsINI := filepath.Join(dir, "mqs.ini")
+	existing, err := existingQueueManagers(mqsINI, qmgrs)
+	if err != nil || len(existing) != 0 {
+		t.Errorf("existingQueueManagers() - expected none on an empty volume, got %v, %v", existing, err)
+	}
+	err = ioutil.WriteFile(mqsINI, []byte(testMQSINI), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// QM1 and APP.QM are in mqs.ini, OLD!QM only has a directory, and
+	// @SYSTEM isn't a queue manager
+	for _, d := range []string{"QM1", "APP!QM", "OLD!QM", "@SYSTEM"} {
+		err = os.MkdirAll(filepath.Join(qmgrs, d), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != "@SYSTEM" {
+			err = ioutil.WriteFile(filepath.Join(qmgrs, d, "qm.ini"), []byte("Log:\n"), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	existing, err = existingQueueManagers(mqsINI, qmgrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"APP.QM", "OLD.QM", "QM1"}
+	if !reflect.DeepEqual(existing, expected) {
+		t.Errorf("existingQueueManagers() - expected %v, got %v", expected, existing)
+	}
+}
+
+var resolveExistingTests = []struct {
+	name     string
+	legacy   string
+	existing []string
+	adopt    bool
+	result   string
+	err      bool
+}{
+	{"QM1", "", nil, false, "QM1", false},
+	{"QM1", "", []string{"QM1"}, false, "QM1", false},
+	{"QM1", "", []string{"QM0", "QM1"}, true, "QM1", false},
+	{"mqpod2", "", []string{"mqpod1"}, false, "", true},
+	{"mqpod2", "", []string{"mqpod1"}, true, "mqpod1", false},
+	{"mqpod3", "", []string{"mqpod1", "mqpod2"}, true, "", true},
+	{"qm1", "", []string{"QM1"}, false, "", true},
+	// A queue manager created from host name "mq-0" by an earlier version
+	{"mq_0", "mq0", []string{"mq0"}, false, "mq0", false},
+	{"mq_0", "mq0", []string{"mq_0", "mq0"}, false, "mq_0", false},
+	{"mq_1", "mq1", []string{"mq0"}, false, "", true},
+}
+
+func TestResolveExistingQueueManager(t *testing.T) {
+	log, _ = logger.NewLogger(ioutil.Discard, false, logger.FormatBasic, "test")
+	for _, table := range resolveExistingTests {
+		result, err := resolveExistingQueueManager(table.name, table.legacy, table.existing, table.adopt)
+		if table.err {
+			if err == nil {
+				t.Errorf("resolveExistingQueueManager(%v, %v, %v, %v) - expected error, got %v", table.name, table.legacy, table.existing, table.adopt, result)
+			}
+			continue
+		}
+		if err != nil || result != table.result {
+			t.Errorf("resolveExistingQueueManager(%v, %v, %v, %v) - expected %v, got %v, %v", table.name, table.legacy, table.existing, table.adopt, table.result, result, err)
+		}
+	}
+}
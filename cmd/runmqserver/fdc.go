@@ -0,0 +1,178 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/fdc"
+)
+
+// fdcWatcher polls a directory for new FDC files, and for new reports
+// appended to existing FDC files
+type fdcWatcher struct {
+	dir       string
+	countFile string
+	output    func(*fdc.Report)
+	// seen holds the number of reports already processed in each file, and
+	// files holds the details of each file when it was processed.  first
+	// identifies the first report in each file, in case a file is recreated
+	// with the same inode.
+	seen  map[string]int
+	files map[string]os.FileInfo
+	first map[string]string
+	mutex sync.Mutex
+	count int
+	// written is the count last written to countFile, or -1 if it hasn't
+	// been written yet
+	written     int
+	writeFailed bool
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// newFDCWatcher creates a watcher for the specified directory.  Any FDC
+// reports which already exist are ignored.
+func newFDCWatcher(dir string, countFile string, output func(*fdc.Report)) *fdcWatcher {
+	w := &fdcWatcher{
+		dir:       dir,
+		countFile: countFile,
+		output:    output,
+		seen:      make(map[string]int),
+		files:     make(map[string]os.FileInfo),
+		first:     make(map[string]string),
+		written:   -1,
+	}
+	w.scan(false)
+	return w
+}
+
+// scan checks for FDC files which have changed since the last scan, and
+// reports any new FDC reports if required
+func (w *fdcWatcher) scan(report bool) {
+	files, err := ioutil.ReadDir(w.dir)
+	if err != nil {
+		// The directory might not exist yet
+		return
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".FDC") {
+			continue
+		}
+		prev, ok := w.files[file.Name()]
+		if ok && os.SameFile(prev, file) && prev.Size() == file.Size() && prev.ModTime().Equal(file.ModTime()) {
+			continue
+		}
+		f, err := os.Open(filepath.Join(w.dir, file.Name()))
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		reports, err := fdc.Parse(f)
+		f.Close()
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		w.files[file.Name()] = file
+		first := ""
+		if len(reports) > 0 {
+			first = reports[0].DateTime + " " + reports[0].Summary()
+		}
+		// If the file has been deleted and recreated, or truncated, then all
+		// its reports are new
+		if ok && (!os.SameFile(prev, file) || first != w.first[file.Name()]) || len(reports) < w.seen[file.Name()] {
+			w.seen[file.Name()] = 0
+		}
+		w.first[file.Name()] = first
+		if report {
+			for _, r := range reports[w.seen[file.Name()]:] {
+				w.output(r)
+				w.mutex.Lock()
+				w.count++
+				w.mutex.Unlock()
+			}
+		}
+		w.seen[file.Name()] = len(reports)
+	}
+	if report {
+		w.writeCount()
+	}
+}
+
+// writeCount records the number of FDC reports for chkmqhealthy, if it has
+// changed.  A failure is only logged once, rather than on every scan.
+func (w *fdcWatcher) writeCount() {
+	count := w.getCount()
+	if count == w.written {
+		return
+	}
+	err := fdc.WriteCount(w.countFile, count)
+	if err != nil {
+		if !w.writeFailed {
+			log.Errorf("Unable to record the number of FDC reports: %v", err)
+			w.writeFailed = true
+		}
+		return
+	}
+	w.written = count
+	w.writeFailed = false
+}
+
+// start starts polling the directory at the specified interval
+func (w *fdcWatcher) start(interval time.Duration) {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	w.writeCount()
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.scan(true)
+			case <-w.stop:
+				// Pick up any reports written during shutdown
+				w.scan(true)
+				return
+			}
+		}
+	}()
+}
+
+// stopWatching stops polling the directory
+func (w *fdcWatcher) stopWatching() {
+	close(w.stop)
+	<-w.done
+}
+
+// getCount returns the number of FDC reports detected since the watcher started
+func (w *fdcWatcher) getCount() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.count
+}
+
+// logFDC logs a one-line summary of an FDC report
+func logFDC(r *fdc.Report) {
+	log.Error(r.Summary())
+}
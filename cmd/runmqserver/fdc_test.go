@@ -0,0 +1,142 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ibm-messaging/mq-container/internal/fdc"
+	"github.com/ibm-messaging/mq-container/internal/logger"
+)
+
+func fdcReport(probe string) string {
+	return "+-----+\n| IBM MQ First Failure Symptom Report |\n| Probe Id :- " + probe + " |\n+-----+\n"
+}
+
+// TestFDCWatcher checks that only new FDC reports are detected, including
+// reports appended to an existing FDC file
+func TestFDCWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	countFile := filepath.Join(dir, "count", "fdccount")
+	appendFile(t, filepath.Join(dir, "AMQ1.0.FDC"), fdcReport("OLD00001"))
+
+	probes := []string{}
+	w := newFDCWatcher(dir, countFile, func(r *fdc.Report) {
+		probes = append(probes, r.ProbeID)
+	})
+	appendFile(t, filepath.Join(dir, "AMQ2.0.FDC"), fdcReport("NEW00001"))
+	appendFile(t, filepath.Join(dir, "AMQ1.0.FDC"), fdcReport("NEW00002"))
+	appendFile(t, filepath.Join(dir, "AMQERR01.LOG"), fdcReport("LOG00001"))
+	w.scan(true)
+	if len(probes) != 2 {
+		t.Fatalf("Expected 2 new reports, got %v", probes)
+	}
+	// Scanning again without changes shouldn't report anything
+	w.scan(true)
+	if w.getCount() != 2 {
+		t.Errorf("Expected count=2, got %v", w.getCount())
+	}
+	count, err := fdc.ReadCount(countFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected count file to contain 2, got %v", count)
+	}
+}
+
+// TestFDCWatcherWriteCount checks that the count file is only written when
+// the count changes, and that a failure to write it is only logged once
+func TestFDCWatcherWriteCount(t *testing.T) {
+	var buf bytes.Buffer
+	log, _ = logger.NewLogger(&buf, false, logger.FormatBasic, "test")
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	countFile := filepath.Join(dir, "fdccount")
+	w := newFDCWatcher(dir, countFile, func(r *fdc.Report) {})
+	w.writeCount()
+	_, err = os.Stat(countFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replace the file with a directory, so that writing it again fails
+	err = os.Remove(countFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Mkdir(countFile, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.scan(true)
+	if buf.Len() != 0 {
+		t.Errorf("Expected the unchanged count not to be written, got %q", buf.String())
+	}
+	appendFile(t, filepath.Join(dir, "AMQ1.0.FDC"), fdcReport("NEW00001"))
+	w.scan(true)
+	appendFile(t, filepath.Join(dir, "AMQ1.0.FDC"), fdcReport("NEW00002"))
+	w.scan(true)
+	if n := strings.Count(buf.String(), "Unable to record"); n != 1 {
+		t.Errorf("Expected the write failure to be logged once, got %v in %q", n, buf.String())
+	}
+}
+
+// TestFDCWatcherRecreated checks that an FDC file which is deleted and
+// recreated with fewer reports is read from the start
+func TestFDCWatcherRecreated(t *testing.T) {
+	log, _ = logger.NewLogger(ioutil.Discard, false, logger.FormatBasic, "test")
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "AMQ1.0.FDC")
+	appendFile(t, path, fdcReport("OLD00001")+fdcReport("OLD00002"))
+	probes := []string{}
+	w := newFDCWatcher(dir, filepath.Join(dir, "fdccount"), func(r *fdc.Report) {
+		probes = append(probes, r.ProbeID)
+	})
+	// Truncate the file, and write a single report
+	err = ioutil.WriteFile(path, []byte(fdcReport("NEW00001")), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.scan(true)
+	// Delete and recreate the file, with a single report again
+	err = os.Remove(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, fdcReport("NEW00002")+"\n")
+	w.scan(true)
+	expected := []string{"NEW00001", "NEW00002"}
+	if !reflect.DeepEqual(probes, expected) {
+		t.Errorf("Expected reports %v, got %v", expected, probes)
+	}
+}
@@ -0,0 +1,295 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/internal/mqsc"
+)
+
+// includePattern matches an include directive in an MQSC file, such as
+// "#include common/queues.mqsc"
+var includePattern = regexp.MustCompile(`^#include\s+"?([^"]+?)"?\s*$`)
+
+// envPattern matches the allowed values of MQ_ENV
+var envPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// sourceLine is the location of a line in an MQSC file
+type sourceLine struct {
+	file string
+	line int
+}
+
+// mqscScript is an MQSC file, after rendering it as a template and expanding
+// any include directives
+type mqscScript struct {
+	path string
+	text string
+	// lines holds the source of each line in the text
+	lines []sourceLine
+	// includes holds the files included by the script, directly or indirectly
+	includes []string
+	// parts holds the files which were combined to make the script
+	parts []string
+}
+
+// newMQSCScript returns a script which doesn't include any other files
+func newMQSCScript(path string, text string) *mqscScript {
+	s := &mqscScript{path: path, text: text}
+	for i := range strings.Split(text, "\n") {
+		s.lines = append(s.lines, sourceLine{file: path, line: i + 1})
+	}
+	return s
+}
+
+// source returns the file and line number for a line in the script
+func (s *mqscScript) source(line int) sourceLine {
+	if line < 1 || line > len(s.lines) {
+		return sourceLine{file: s.path, line: line}
+	}
+	return s.lines[line-1]
+}
+
+// location returns the file and line number for a line in the script,
+// such as "/etc/mqm/base/queues.mqsc:3"
+func (s *mqscScript) location(line int) string {
+	src := s.source(line)
+	return fmt.Sprintf("%v:%v", src.file, src.line)
+}
+
+// loadMQSCScript renders an MQSC file, and replaces each include directive
+// with the contents of the included file.  Relative paths are relative to
+// the directory of the including file.  The stack holds the files which are
+// currently being included, to detect include cycles.
+func loadMQSCScript(path string, data *mqscData, stack []string) (*mqscScript, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text, err := renderMQSC(path, string(buf), data)
+	if err != nil {
+		return nil, err
+	}
+	s := &mqscScript{path: path}
+	lines := make([]string, 0)
+	for i, line := range strings.Split(text, "\n") {
+		m := includePattern.FindStringSubmatch(strings.TrimRight(line, " \r"))
+		if m == nil {
+			lines = append(lines, line)
+			s.lines = append(s.lines, sourceLine{file: path, line: i + 1})
+			continue
+		}
+		include := m[1]
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		for _, p := range append(stack, path) {
+			if p == include {
+				return nil, fmt.Errorf("%v:%v: Unable to include %v: it is already being included", path, i+1, m[1])
+			}
+		}
+		inc, err := loadMQSCScript(include, data, append(stack, path))
+		if err != nil {
+			return nil, fmt.Errorf("%v:%v: Unable to include %v: %v", path, i+1, m[1], err)
+		}
+		incLines := strings.Split(inc.text, "\n")
+		if incLines[len(incLines)-1] == "" {
+			// Don't add a blank line for the final newline
+			incLines = incLines[:len(incLines)-1]
+		}
+		lines = append(lines, incLines...)
+		s.lines = append(s.lines, inc.lines[:len(incLines)]...)
+		s.includes = append(s.includes, include)
+		s.includes = append(s.includes, inc.includes...)
+	}
+	s.text = strings.Join(lines, "\n")
+	return s, nil
+}
+
+// combineScripts joins several scripts into one, so that they can be run in a
+// single runmqsc session.  Each file starts with a comment naming the file,
+// which appears in the output of runmqsc.  END commands are commented out, so
+// that they don't end the session before the later files have been run.
+func combineScripts(scripts []*mqscScript) (*mqscScript, error) {
+	c := &mqscScript{path: fmt.Sprintf("%v MQSC files", len(scripts))}
+	lines := make([]string, 0)
+	for _, s := range scripts {
+		if line, ok := mqsc.ContinuedAtEnd(s.text); ok {
+			// The command would be joined with the first one in the next file
+			return nil, fmt.Errorf("%v: command is continued past the end of the file", s.location(line))
+		}
+		text := strings.Split(strings.TrimSuffix(s.text, "\n"), "\n")
+		for _, cmd := range mqsc.Split(s.text) {
+			if strings.EqualFold(cmd.Text, "END") {
+				text[cmd.Line-1] = "* " + text[cmd.Line-1]
+			}
+		}
+		lines = append(lines, "* File: "+s.path)
+		c.lines = append(c.lines, sourceLine{file: s.path, line: 0})
+		lines = append(lines, text...)
+		c.lines = append(c.lines, s.lines[:len(text)]...)
+		c.includes = append(c.includes, s.includes...)
+		c.parts = append(c.parts, s.path)
+	}
+	c.text = strings.Join(lines, "\n") + "\n"
+	return c, nil
+}
+
+// findMQSCFiles returns the MQSC files in a directory and its
+// subdirectories, sorted by path.  If the directory doesn't exist, then
+// there are no files.  Files and directories starting with ".." are ignored,
+// because Kubernetes uses them to hold the real files in a ConfigMap volume,
+// which are linked from the top of the volume.
+func findMQSCFiles(dir string) ([]string, error) {
+	files := make([]string, 0)
+	_, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return files, nil
+	}
+	// Walk visits the files in lexical order, so the order is deterministic
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(info.Name(), "..") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".mqsc") {
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			// Follow links to files, such as those in a ConfigMap volume
+			fi, err := os.Stat(path)
+			if err != nil || fi.IsDir() {
+				return err
+			}
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files, err
+}
+
+// overlayDir returns the overlay directory for an environment
+func overlayDir(dir string, env string) string {
+	return filepath.Join(dir, "overlays", env)
+}
+
+// listOverlays returns the environments which have an overlay directory
+func listOverlays(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(dir, "overlays"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	envs := make([]string, 0)
+	for _, file := range files {
+		if file.IsDir() {
+			envs = append(envs, file.Name())
+		}
+	}
+	return envs, nil
+}
+
+// layoutFiles returns the MQSC files to apply from a configuration
+// directory, in order.  Files at the top level of the directory are applied
+// first, followed by the files in the "base" directory, and then the files
+// in the overlay directory for each environment.  Files in the base and
+// overlay directories are applied in order of their path, including any
+// subdirectories.
+func layoutFiles(dir string, envs []string) ([]string, error) {
+	files, err := mqscFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	base, err := findMQSCFiles(filepath.Join(dir, "base"))
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, base...)
+	for _, env := range envs {
+		if !envPattern.MatchString(env) || env == "." || env == ".." {
+			return nil, fmt.Errorf("Invalid value for MQ_ENV: %v", env)
+		}
+		overlay := overlayDir(dir, env)
+		fi, err := os.Stat(overlay)
+		if err != nil || !fi.IsDir() {
+			return nil, fmt.Errorf("No overlay directory %v found for MQ_ENV=%v", overlay, env)
+		}
+		more, err := findMQSCFiles(overlay)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, more...)
+	}
+	return files, nil
+}
+
+// loadLayout renders each of the MQSC files, and expands any include
+// directives.  Files which are included by another file aren't applied on
+// their own.
+func loadLayout(files []string, data *mqscData) ([]*mqscScript, error) {
+	scripts := make([]*mqscScript, 0)
+	included := make(map[string]bool)
+	for _, file := range files {
+		s, err := loadMQSCScript(file, data, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, inc := range s.includes {
+			included[filepath.Clean(inc)] = true
+		}
+		scripts = append(scripts, s)
+	}
+	result := make([]*mqscScript, 0)
+	for _, s := range scripts {
+		if !included[filepath.Clean(s.path)] {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+// logLayout logs the MQSC files which will be applied, along with any files
+// they include
+func logLayout(env string, scripts []*mqscScript) {
+	if len(scripts) == 0 {
+		return
+	}
+	if env == "" {
+		log.Printf("Applying %v MQSC files", len(scripts))
+	} else {
+		log.Printf("Applying %v MQSC files for environment %v", len(scripts), env)
+	}
+	for _, s := range scripts {
+		if len(s.includes) == 0 {
+			log.Printf("  %v", s.path)
+		} else {
+			log.Printf("  %v (including %v)", s.path, strings.Join(s.includes, ", "))
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeLayout creates a temporary configuration directory containing the
+// specified files
+func writeLayout(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "layout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+var layout = map[string]string{
+	"top.mqsc":                      "DEFINE QLOCAL(TOP)\n",
+	"base/b.mqsc":                   "DEFINE QLOCAL(B)\n",
+	"base/a/z.mqsc":                 "DEFINE QLOCAL(AZ)\n",
+	"base/common/queues.inc":        "DEFINE QLOCAL(COMMON)\n",
+	"overlays/dev/dev.mqsc":         "DEFINE QLOCAL(DEV)\n",
+	"overlays/prod/prod.mqsc":       "#include ../../base/common/queues.inc\n* Production only\nDEFINE QLOCAL(PROD)\n",
+	"overlays/prod/channels.mqsc":   "#include \"shared.mqsc\"\nDEFINE CHANNEL(PROD) CHLTYPE(SVRCONN)\n",
+	"overlays/prod/shared.mqsc":     "DEFINE QLOCAL(SHARED)\n",
+	"overlays/test/loop.mqsc":       "#include loop.mqsc\n",
+	"overlays/missing/broken.mqsc":  "#include nothere.mqsc\n",
+	"migrations/V001__ignored.mqsc": "DELETE QLOCAL(OLD)\n",
+}
+
+var layoutFilesTests = []struct {
+	envs  []string
+	files []string
+	err   bool
+}{
+	{nil, []string{"top.mqsc", "base/a/z.mqsc", "base/b.mqsc"}, false},
+	{[]string{"dev"}, []string{"top.mqsc", "base/a/z.mqsc", "base/b.mqsc", "overlays/dev/dev.mqsc"}, false},
+	{[]string{"prod"}, []string{"top.mqsc", "base/a/z.mqsc", "base/b.mqsc", "overlays/prod/channels.mqsc", "overlays/prod/prod.mqsc", "overlays/prod/shared.mqsc"}, false},
+	{[]string{"unknown"}, nil, true},
+	{[]string{"../base"}, nil, true},
+}
+
+func TestLayoutFiles(t *testing.T) {
+	dir := writeLayout(t, layout)
+	defer os.RemoveAll(dir)
+	for _, table := range layoutFilesTests {
+		files, err := layoutFiles(dir, table.envs)
+		if table.err {
+			if err == nil {
+				t.Errorf("layoutFiles(%v) - expected error, got %v", table.envs, files)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("layoutFiles(%v) - unexpected error: %v", table.envs, err)
+			continue
+		}
+		for i := range files {
+			files[i] = strings.TrimPrefix(files[i], dir+"/")
+		}
+		if !reflect.DeepEqual(files, table.files) {
+			t.Errorf("layoutFiles(%v) - expected %v, got %v", table.envs, table.files, files)
+		}
+	}
+}
+
+func TestLoadLayout(t *testing.T) {
+	dir := writeLayout(t, layout)
+	defer os.RemoveAll(dir)
+	files, err := layoutFiles(dir, []string{"prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scripts, err := loadLayout(files, &mqscData{QueueManager: "QM1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make([]string, len(scripts))
+	for i, s := range scripts {
+		paths[i] = strings.TrimPrefix(s.path, dir+"/")
+	}
+	// shared.mqsc is included by channels.mqsc, so isn't applied on its own
+	expected := []string{"top.mqsc", "base/a/z.mqsc", "base/b.mqsc", "overlays/prod/channels.mqsc", "overlays/prod/prod.mqsc"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("loadLayout() - expected %v, got %v", expected, paths)
+	}
+	prod := scripts[4]
+	text := "DEFINE QLOCAL(COMMON)\n* Production only\nDEFINE QLOCAL(PROD)\n"
+	if prod.text != text {
+		t.Errorf("loadLayout() - expected %q, got %q", text, prod.text)
+	}
+	locations := []string{"base/common/queues.inc:1", "overlays/prod/prod.mqsc:2", "overlays/prod/prod.mqsc:3"}
+	for i, l := range locations {
+		loc := strings.TrimPrefix(prod.location(i+1), dir+"/")
+		if loc != l {
+			t.Errorf("location(%v) - expected %v, got %v", i+1, l, loc)
+		}
+	}
+}
+
+var loadLayoutErrorTests = []string{"test", "missing"}
+
+func TestLoadLayoutErrors(t *testing.T) {
+	dir := writeLayout(t, layout)
+	defer os.RemoveAll(dir)
+	for _, env := range loadLayoutErrorTests {
+		files, err := layoutFiles(dir, []string{env})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = loadLayout(files, &mqscData{})
+		if err == nil {
+			t.Errorf("loadLayout(%v) - expected error", env)
+		}
+	}
+}
+
+// TestFindMQSCFilesConfigMap checks that each file in a Kubernetes ConfigMap
+// volume is only found once, through the link at the top of the volume
+func TestFindMQSCFilesConfigMap(t *testing.T) {
+	dir := writeLayout(t, map[string]string{
+		"..2017_11_06_10_21_45.123456789/queues.mqsc": "DEFINE QLOCAL(A)\n",
+	})
+	defer os.RemoveAll(dir)
+	err := os.Symlink("..2017_11_06_10_21_45.123456789", filepath.Join(dir, "..data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Symlink(filepath.Join("..data", "queues.mqsc"), filepath.Join(dir, "queues.mqsc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := findMQSCFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{filepath.Join(dir, "queues.mqsc")}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("findMQSCFiles() - expected %v, got %v", expected, files)
+	}
+}
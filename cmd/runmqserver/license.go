@@ -18,7 +18,6 @@ package main
 import (
 	"errors"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -81,13 +80,13 @@ func checkLicense() (bool, error) {
 		file := filepath.Join("/opt/mqm/licenses", resolveLicenseFile())
 		buf, err := ioutil.ReadFile(file)
 		if err != nil {
-			log.Println(err)
+			log.Error(err)
 			return false, err
 		}
 		log.Println(string(buf))
 		return false, nil
 	}
-	log.Println("Error: Set environment variable LICENSE=accept to indicate acceptance of license terms and conditions.")
-	log.Println("License agreements and information can be viewed by setting the environment variable LICENSE=view.  You can also set the LANG environment variable to view the license in a different language.")
+	log.Error("Set environment variable LICENSE=accept to indicate acceptance of license terms and conditions.")
+	log.Error("License agreements and information can be viewed by setting the environment variable LICENSE=view.  You can also set the LANG environment variable to view the license in a different language.")
 	return false, errors.New("Set environment variable LICENSE=accept to indicate acceptance of license terms and conditions")
 }
@@ -20,53 +20,61 @@ package main
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/ibm-messaging/mq-container/internal/command"
+	"github.com/ibm-messaging/mq-container/internal/fdc"
+	"github.com/ibm-messaging/mq-container/internal/listener"
+	"github.com/ibm-messaging/mq-container/internal/logger"
 	"github.com/ibm-messaging/mq-container/internal/name"
 )
 
-var debug = false
+// Phases of processing, which are included in JSON log messages
+const (
+	phaseLicense  string = "license"
+	phaseVolume   string = "volume"
+	phaseCrtmqm   string = "crtmqm"
+	phaseStrmqm   string = "strmqm"
+	phaseMQSC     string = "mqsc"
+	phaseRunning  string = "running"
+	phaseShutdown string = "shutdown"
+)
 
-func logDebug(msg string) {
-	if debug {
-		log.Printf("DEBUG: %v", msg)
-	}
-}
-
-func logDebugf(format string, args ...interface{}) {
-	if debug {
-		log.Printf("DEBUG: %v", fmt.Sprintf(format, args...))
-	}
-}
+var log *logger.Logger
 
 // createDirStructure creates the default MQ directory structure under /var/mqm
 func createDirStructure() error {
 	out, _, err := command.Run("/opt/mqm/bin/crtmqdir", "-f", "-s")
 	if err != nil {
-		log.Printf("Error creating directory structure: %v\n", string(out))
+		log.Errorf("Error creating directory structure: %v", string(out))
 		return err
 	}
 	log.Println("Created directory structure under /var/mqm")
 	return nil
 }
 
-func createQueueManager(name string) error {
+// createQueueManager creates the queue manager, if it doesn't already exist.
+// The default listener is only configured by crtmqm if no listeners have
+// been set using MQ_LISTENER_PORTS.
+func createQueueManager(name string, options *createOptions, listeners []listener.Listener) error {
 	log.Printf("Creating queue manager %v", name)
-	out, rc, err := command.Run("crtmqm", "-q", "-p", "1414", name)
+	args := []string{"-q"}
+	if listeners == nil {
+		args = append(args, "-p", strconv.Itoa(listener.DefaultPort))
+	}
+	args = append(args, options.args()...)
+	out, rc, err := command.Run("crtmqm", append(args, name)...)
 	if err != nil {
 		// 8=Queue manager exists, which is fine
 		if rc != 8 {
-			log.Printf("crtmqm returned %v", rc)
-			log.Println(string(out))
+			log.Errorf("crtmqm returned %v", rc)
+			log.Error(string(out))
 			return err
 		}
 		log.Printf("Detected existing queue manager %v", name)
+		checkExistingQueueManager(name, options)
 	}
 	return nil
 }
@@ -76,7 +84,7 @@ func updateCommandLevel() error {
 	if ok && level != "" {
 		out, rc, err := command.Run("strmqm", "-e", "CMDLEVEL="+level)
 		if err != nil {
-			log.Printf("Error %v setting CMDLEVEL: %v", rc, string(out))
+			log.Errorf("Error %v setting CMDLEVEL: %v", rc, string(out))
 			return err
 		}
 	}
@@ -87,65 +95,36 @@ func startQueueManager() error {
 	log.Println("Starting queue manager")
 	out, rc, err := command.Run("strmqm")
 	if err != nil {
-		log.Printf("Error %v starting queue manager: %v", rc, string(out))
+		log.Errorf("Error %v starting queue manager: %v", rc, string(out))
 		return err
 	}
 	log.Println("Started queue manager")
 	return nil
 }
 
-func configureQueueManager() error {
-	const configDir string = "/etc/mqm"
-	files, err := ioutil.ReadDir(configDir)
-	if err != nil {
-		log.Println(err)
-		return err
-	}
-
-	for _, file := range files {
-		if strings.HasSuffix(file.Name(), ".mqsc") {
-			abs := filepath.Join(configDir, file.Name())
-			mqsc, err := ioutil.ReadFile(abs)
-			if err != nil {
-				log.Println(err)
-				return err
-			}
-			cmd := exec.Command("runmqsc")
-			stdin, err := cmd.StdinPipe()
-			if err != nil {
-				log.Println(err)
-				return err
-			}
-			stdin.Write(mqsc)
-			stdin.Close()
-			// Run the command and wait for completion
-			out, err := cmd.CombinedOutput()
-			if err != nil {
-				log.Println(err)
-			}
-			// Print the runmqsc output, adding tab characters to make it more readable as part of the log
-			log.Printf("Output for \"runmqsc\" with %v:\n\t%v", abs, strings.Replace(string(out), "\n", "\n\t", -1))
-		}
+// getEnvInt returns the value of an environment variable as a non-negative
+// integer, or the default value if the variable isn't set
+func getEnvInt(name string, def int) (int, error) {
+	s, ok := os.LookupEnv(name)
+	if !ok || s == "" {
+		return def, nil
 	}
-	return nil
-}
-
-func stopQueueManager(name string) error {
-	log.Println("Stopping queue manager")
-	out, _, err := command.Run("endmqm", "-w", name)
-	if err != nil {
-		log.Printf("Error stopping queue manager: %v", string(out))
-		return err
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("Invalid value for %v: %v", name, s)
 	}
-	log.Println("Stopped queue manager")
-	return nil
+	return i, nil
 }
 
 func doMain() error {
-	debugEnv, ok := os.LookupEnv("DEBUG")
-	if ok && (debugEnv == "true" || debugEnv == "1") {
-		debug = true
+	var err error
+	log, err = logger.NewLoggerFromEnv()
+	if err != nil {
+		// The logger isn't available, so write the error directly
+		fmt.Fprintln(os.Stderr, err)
+		return err
 	}
+	log.SetPhase(phaseLicense)
 	accepted, err := checkLicense()
 	if err != nil {
 		return err
@@ -156,28 +135,96 @@ func doMain() error {
 
 	name, err := name.GetQueueManagerName()
 	if err != nil {
-		log.Println(err)
+		log.Error(err)
+		return err
+	}
+	name, err = checkExistingQueueManagers(name)
+	if err != nil {
+		log.Error(err)
 		return err
 	}
+	log.SetQueueManager(name)
 	log.Printf("Using queue manager name: %v", name)
+	filter, err := newErrorLogFilterFromEnv()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	options, err := createOptionsFromEnv()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	config, err := configPolicyFromEnv()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	restart, err := restartPolicyFromEnv()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	shutdown, err := shutdownPolicyFromEnv()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	watcher, err := configWatcherFromEnv()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	var configChanges <-chan struct{}
+	if watcher != nil {
+		configChanges = watcher.changes
+	}
+	reload := func() error {
+		return configureQueueManager(name, config)
+	}
 
 	// Start signal handler
-	signalControl := signalHandler(name)
+	signalControl, done, stopped := signalHandler(name, restart, shutdown, reload, configChanges)
 
-	logConfig()
+	log.SetPhase(phaseVolume)
+	err = logConfig()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
 	err = createVolume("/mnt/mqm")
 	if err != nil {
-		log.Println(err)
+		log.Error(err)
 		return err
 	}
+	// Record the position in the error logs to mirror from, once the queue
+	// manager has started
+	logOffsets := logOffsets(errorLogs(name), mirrorStateFile)
 	err = createDirStructure()
 	if err != nil {
 		return err
 	}
-	err = createQueueManager(name)
+	log.SetPhase(phaseCrtmqm)
+	err = createQueueManager(name, options, config.listeners)
 	if err != nil {
 		return err
 	}
+	err = updateQMINI(name)
+	if err != nil {
+		return err
+	}
+	// Record any existing FDC files, so that only new ones are reported
+	fdcs := newFDCWatcher("/var/mqm/errors", fdc.CountFile, logFDC)
+	// Don't start the queue manager if a signal has already stopped it
+	if isStopped(stopped) {
+		return <-done
+	}
+	log.SetPhase(phaseStrmqm)
 	err = updateCommandLevel()
 	if err != nil {
 		return err
@@ -186,21 +233,60 @@ func doMain() error {
 	if err != nil {
 		return err
 	}
-	configureQueueManager()
+	mirror, err := mirrorLogs(logOffsets, mirrorStateFile, errorLogOutput(filter))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	// Stop mirroring once the queue manager has been stopped
+	defer mirror.stop()
+	fdcs.start(5 * time.Second)
+	defer fdcs.stopWatching()
+	log.SetPhase(phaseMQSC)
+	err = configureQueueManager(name, config)
+	if err != nil {
+		return err
+	}
+	log.SetPhase(phaseRunning)
 	// Start reaping zombies from now on.
 	// Start this here, so that we don't reap any sub-processes created
-	// by this process (e.g. for crtmqm or strmqm)
-	signalControl <- startReaping
-	// Reap zombies now, just in case we've already got some
-	signalControl <- reapNow
-	// Wait for terminate signal
-	<-signalControl
-	return nil
+	// by this process (e.g. for crtmqm or strmqm).  If a signal has stopped
+	// the queue manager during startup, then the jobs aren't received.
+	// Reap zombies now, just in case we've already got some.
+	// Exit if the queue manager stops, without being asked to.
+	for _, job := range []int{startReaping, reapNow, startMonitoring} {
+		if !sendControl(signalControl, stopped, job) {
+			return <-done
+		}
+	}
+	if watcher != nil {
+		log.Printf("Watching for changes to the configuration in %v", watcher.dir)
+		watcher.start(configWatchInterval)
+		defer watcher.stopWatching()
+	}
+	// Wait for terminate signal, or for the queue manager to end
+	return <-done
 }
 
 var osExit = os.Exit
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "render-config" {
+		err := renderConfig(os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			osExit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "check-config" {
+		err := checkConfig(os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			osExit(1)
+		}
+		return
+	}
 	err := doMain()
 	if err != nil {
 		osExit(1)
@@ -17,8 +17,8 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
-	"log"
 	"strconv"
 	"testing"
 )
@@ -40,10 +40,10 @@ func TestSystem(t *testing.T) {
 		}()
 		osExit = func(rc int) {
 			// Write the exit code to a file instead
-			log.Printf("Writing exit code %v to file %v", strconv.Itoa(rc), filename)
+			fmt.Printf("Writing exit code %v to file %v\n", strconv.Itoa(rc), filename)
 			err := ioutil.WriteFile(filename, []byte(strconv.Itoa(rc)), 0644)
 			if err != nil {
-				log.Print(err)
+				fmt.Println(err)
 			}
 		}
 		main()
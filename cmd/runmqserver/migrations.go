@@ -0,0 +1,86 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/ibm-messaging/mq-container/internal/migrate"
+)
+
+// migrationStateFile records the migrations which have been applied to the
+// queue manager, on the same volume as the queue manager's data
+const migrationStateFile string = "/mnt/mqm/data/migrations.json"
+
+// migrationsDir returns the directory holding the migrations
+func migrationsDir(dir string) string {
+	return filepath.Join(dir, "migrations")
+}
+
+// applyMigrations runs each migration which hasn't already been applied to
+// the queue manager, in order of version, and records it in the state file.
+// Each migration is only applied once, so if any of its commands fail, then
+// an error is returned, and later migrations aren't applied.
+func applyMigrations(dir string, stateFile string, data *mqscData) error {
+	migrations, err := migrate.Find(dir)
+	if err != nil {
+		return err
+	}
+	state, err := migrate.LoadState(stateFile)
+	if err != nil {
+		return err
+	}
+	pending, err := state.Pending(migrations)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		if len(migrations) > 0 {
+			log.Printf("All %v migrations have already been applied", len(migrations))
+		}
+		return nil
+	}
+	log.Printf("Applying %v pending migrations", len(pending))
+	for _, m := range pending {
+		buf, err := ioutil.ReadFile(m.Path)
+		if err != nil {
+			return err
+		}
+		if migrate.Checksum(buf) != m.Checksum {
+			return fmt.Errorf("Migration %v changed while it was being applied", m)
+		}
+		script, err := renderMQSC(m.Path, string(buf), data)
+		if err != nil {
+			return err
+		}
+		n, err := runMQSCFile(m.Path, script)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("Migration %v failed: %v MQSC commands failed", m, n)
+		}
+		state.Record(m)
+		err = state.Save(stateFile)
+		if err != nil {
+			return fmt.Errorf("Migration %v was applied, but couldn't be recorded: %v", m, err)
+		}
+		log.Printf("Applied migration %v: %v", m, m.Description)
+	}
+	return nil
+}
@@ -0,0 +1,401 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hpcloud/tail"
+	"github.com/ibm-messaging/mq-container/internal/errorlog"
+)
+
+// mirrorStateFile records how much of each error log has been mirrored, so
+// that a new container carries on from where the previous one stopped
+const mirrorStateFile string = "/mnt/mqm/data/mirror.json"
+
+// logPosition is the position up to which a log file has been mirrored.  The
+// inode identifies the file, so that a file which has been rotated since
+// isn't resumed at the wrong position.
+type logPosition struct {
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+// logMirror copies lines from one or more log files to a callback function
+type logMirror struct {
+	tails     []*tail.Tail
+	wg        sync.WaitGroup
+	stateFile string
+	// mutex protects the positions, which are updated by each tail
+	mutex      sync.Mutex
+	positions  map[string]logPosition
+	changed    bool
+	saveFailed bool
+	saveStop   chan struct{}
+	saveDone   chan struct{}
+}
+
+// mirrorSaveInterval is how often the positions are saved, if they have
+// changed.  A new container might repeat the entries mirrored during the last
+// interval.
+var mirrorSaveInterval = 5 * time.Second
+
+// queueManagerDataDir returns the name of the directory used by MQ to hold
+// data for the specified queue manager.  MQ transforms some characters which
+// are valid in a queue manager name, but which are awkward in file names.
+func queueManagerDataDir(name string) string {
+	dir := strings.Replace(name, ".", "!", -1)
+	return strings.Replace(dir, "/", "&", -1)
+}
+
+// errorLogs returns the paths of the error logs which should be mirrored for
+// the specified queue manager: the queue manager's own error log, plus the
+// system-wide error log
+func errorLogs(qmgr string) []string {
+	return []string{
+		filepath.Join("/var/mqm/qmgrs", queueManagerDataDir(qmgr), "errors", "AMQERR01.LOG"),
+		filepath.Join("/var/mqm/errors", "AMQERR01.LOG"),
+	}
+}
+
+// fileInode returns the inode number of a file
+func fileInode(fi os.FileInfo) uint64 {
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return stat.Ino
+	}
+	return 0
+}
+
+// loadLogPositions reads the positions saved by a previous container.  If
+// the file doesn't exist, then no positions are returned.
+func loadLogPositions(stateFile string) (map[string]logPosition, error) {
+	positions := make(map[string]logPosition)
+	data, err := ioutil.ReadFile(stateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return positions, nil
+		}
+		return nil, err
+	}
+	err = json.Unmarshal(data, &positions)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid error log state file %v: %v", stateFile, err)
+	}
+	return positions, nil
+}
+
+// saveLogPositions writes the positions.  The file is replaced atomically, so
+// that it isn't left incomplete if the container is stopped while writing it.
+func saveLogPositions(stateFile string, positions map[string]logPosition) error {
+	data, err := json.Marshal(positions)
+	if err != nil {
+		return err
+	}
+	tmp := stateFile + ".tmp"
+	err = ioutil.WriteFile(tmp, append(data, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, stateFile)
+}
+
+// logOffsets returns the offset in each of the specified files from which
+// entries should be mirrored.  If a previous container saved its position in
+// the state file, then mirroring resumes from there, or from the start of the
+// file if it has been rotated since.  Otherwise, the offset is the current
+// size of the file, which marks the point from which new entries will be
+// written.  Files which don't exist yet have an offset of zero.
+func logOffsets(paths []string, stateFile string) map[string]int64 {
+	saved, err := loadLogPositions(stateFile)
+	if err != nil {
+		log.Printf("Warning: only new error log entries will be mirrored: %v", err)
+		saved = make(map[string]logPosition)
+	}
+	offsets := make(map[string]int64)
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		pos, ok := saved[p]
+		switch {
+		case err != nil:
+			offsets[p] = 0
+		case !ok:
+			offsets[p] = fi.Size()
+		case pos.Inode != fileInode(fi):
+			offsets[p] = 0
+		default:
+			offsets[p] = pos.Offset
+		}
+	}
+	return offsets
+}
+
+// logFile tracks the position which has been mirrored in a log file, and
+// follows the file when it is rotated
+type logFile struct {
+	path string
+	// file is the file being read, or nil if it didn't exist
+	file os.FileInfo
+	// next is the file which has replaced file, once it has been rotated, and
+	// end is the size of the rotated file
+	next os.FileInfo
+	end  int64
+	pos  logPosition
+}
+
+// rotatedSize returns the size of a log file which has been renamed within
+// the same directory, or def if it can't be found
+func rotatedSize(dir string, file os.FileInfo, def int64) int64 {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return def
+	}
+	for _, fi := range files {
+		if os.SameFile(fi, file) {
+			return fi.Size()
+		}
+	}
+	return def
+}
+
+// advance records that a line of n bytes has been mirrored.  When the file is
+// rotated, the tail reads the rest of the old file before re-opening the path,
+// so the position moves to the new file once the old one has been finished.
+func (f *logFile) advance(n int64) {
+	if f.file == nil || f.next == nil {
+		fi, err := os.Stat(f.path)
+		switch {
+		case err != nil:
+		case f.file == nil:
+			f.file = fi
+			f.pos = logPosition{Inode: fileInode(fi)}
+		case !os.SameFile(fi, f.file):
+			f.next = fi
+			f.end = rotatedSize(filepath.Dir(f.path), f.file, f.pos.Offset)
+		}
+	}
+	if f.next != nil && f.pos.Offset >= f.end {
+		f.file = f.next
+		f.next = nil
+		f.pos = logPosition{Inode: fileInode(f.file)}
+	}
+	f.pos.Offset += n
+}
+
+// record records the position which has been mirrored in a file.  The
+// positions are saved periodically, rather than for every line.
+func (m *logMirror) record(path string, pos logPosition) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.positions[path] = pos
+	m.changed = true
+}
+
+// save saves the positions, if they have changed since they were last saved.
+// A failure is only logged once, rather than every time.
+func (m *logMirror) save() {
+	if m.stateFile == "" {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if !m.changed {
+		return
+	}
+	err := saveLogPositions(m.stateFile, m.positions)
+	if err != nil {
+		if !m.saveFailed {
+			log.Errorf("Unable to record the error log position: %v", err)
+			m.saveFailed = true
+		}
+		return
+	}
+	m.changed = false
+	m.saveFailed = false
+}
+
+// startSaving starts saving the positions at the specified interval
+func (m *logMirror) startSaving(interval time.Duration) {
+	m.saveStop = make(chan struct{})
+	m.saveDone = make(chan struct{})
+	go func() {
+		defer close(m.saveDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.save()
+			case <-m.saveStop:
+				return
+			}
+		}
+	}()
+}
+
+// mirrorLogs starts tailing each of the specified files, from the given
+// offset.  The newOutput function is called once for each file, and returns
+// the function which is called with each new line.  Offsets should
+// be recorded before the queue manager is started, so that entries from a
+// previous run (for example, before a container restart) aren't repeated,
+// but entries written during startup aren't lost.  The position in each file
+// is saved in the state file periodically, and when mirroring stops, unless
+// stateFile is empty.
+// The files don't need to exist yet, and are re-opened if MQ rotates them.
+func mirrorLogs(offsets map[string]int64, stateFile string, newOutput func() func(string)) (*logMirror, error) {
+	m := &logMirror{
+		stateFile: stateFile,
+		positions: make(map[string]logPosition),
+	}
+	// Keep the positions of any other files from the previous run
+	saved, err := loadLogPositions(stateFile)
+	if err == nil {
+		m.positions = saved
+	}
+	for path, offset := range offsets {
+		f := &logFile{path: path}
+		fi, err := os.Stat(path)
+		// If the file is now smaller than the recorded offset, then it has
+		// been rotated since, so start from the beginning of the new file
+		if err == nil && fi.Size() < offset {
+			offset = 0
+		}
+		if err == nil {
+			f.file = fi
+			f.pos = logPosition{Inode: fileInode(fi), Offset: offset}
+		}
+		t, err := tail.TailFile(path, tail.Config{
+			Location: &tail.SeekInfo{Offset: offset, Whence: io.SeekStart},
+			// Re-open the file when MQ rotates the error logs
+			ReOpen:    true,
+			Follow:    true,
+			MustExist: false,
+			// Polling works on network file systems, where inotify doesn't
+			Poll:   true,
+			Logger: tail.DiscardingLogger,
+		})
+		if err != nil {
+			m.stop()
+			return nil, err
+		}
+		m.tails = append(m.tails, t)
+		m.wg.Add(1)
+		output := newOutput()
+		go func() {
+			defer m.wg.Done()
+			for line := range t.Lines {
+				output(line.Text)
+				// The tail removes the newline from the end of each line
+				f.advance(int64(len(line.Text)) + 1)
+				m.record(f.path, f.pos)
+			}
+		}()
+	}
+	m.startSaving(mirrorSaveInterval)
+	return m, nil
+}
+
+// stop stops mirroring, once any remaining lines in the files have been
+// processed.  This allows messages written during queue manager shutdown to
+// be mirrored.
+func (m *logMirror) stop() {
+	for _, t := range m.tails {
+		t.StopAtEOF()
+	}
+	m.wg.Wait()
+	if m.saveStop != nil {
+		close(m.saveStop)
+		<-m.saveDone
+	}
+	m.save()
+}
+
+// errorLogFilter decides which error log entries are mirrored
+type errorLogFilter struct {
+	minSeverity errorlog.Severity
+	// exclude holds message IDs, without the severity (for example "AMQ5051")
+	exclude map[string]bool
+}
+
+// messageNumber returns a message ID without the severity character, so that
+// "AMQ5051I" and "AMQ5051" are treated the same
+func messageNumber(id string) string {
+	return strings.TrimRight(strings.ToUpper(strings.TrimSpace(id)), "IWEST")
+}
+
+// newErrorLogFilterFromEnv creates a filter using the minimum severity in the
+// MQ_ERRORLOG_SEVERITY environment variable, and the comma-separated list of
+// message IDs in the MQ_ERRORLOG_EXCLUDE environment variable
+func newErrorLogFilterFromEnv() (*errorLogFilter, error) {
+	f := &errorLogFilter{
+		minSeverity: errorlog.SeverityInformation,
+		exclude:     make(map[string]bool),
+	}
+	sev, ok := os.LookupEnv("MQ_ERRORLOG_SEVERITY")
+	if ok && sev != "" {
+		var err error
+		f.minSeverity, err = errorlog.ParseSeverity(sev)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value for MQ_ERRORLOG_SEVERITY: %v", sev)
+		}
+	}
+	for _, id := range strings.Split(os.Getenv("MQ_ERRORLOG_EXCLUDE"), ",") {
+		if strings.TrimSpace(id) != "" {
+			f.exclude[messageNumber(id)] = true
+		}
+	}
+	return f, nil
+}
+
+// include returns true if the entry should be mirrored.  Entries from older
+// versions of MQ don't have a severity, so are only filtered by message ID.
+func (f *errorLogFilter) include(e *errorlog.Entry) bool {
+	if f.exclude[messageNumber(e.MessageID)] {
+		return false
+	}
+	return e.Severity == errorlog.SeverityUnknown || e.Severity >= f.minSeverity
+}
+
+// errorLogOutput returns a function suitable for use with mirrorLogs, which
+// parses each error log entry, and logs the full text of the entry if it
+// passes the filter.  Entries which can't be parsed are logged as they are.
+func errorLogOutput(f *errorLogFilter) func() func(string) {
+	return func() func(string) {
+		p := &errorlog.Parser{}
+		return func(line string) {
+			e, lines, err := p.ParseLine(line)
+			text := strings.TrimSpace(strings.Join(lines, "\n"))
+			switch {
+			case err != nil:
+				log.Println(text)
+			case e == nil || !f.include(e):
+				return
+			case e.Severity >= errorlog.SeverityError:
+				log.Error(text)
+			default:
+				log.Println(text)
+			}
+		}
+	}
+}
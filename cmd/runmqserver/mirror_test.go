@@ -0,0 +1,289 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/errorlog"
+	"github.com/ibm-messaging/mq-container/internal/logger"
+)
+
+var dataDirTests = []struct {
+	in  string
+	out string
+}{
+	{"qm1", "qm1"},
+	{"qm.1", "qm!1"},
+	{"qm/1", "qm&1"},
+}
+
+func TestQueueManagerDataDir(t *testing.T) {
+	for _, table := range dataDirTests {
+		s := queueManagerDataDir(table.in)
+		if s != table.out {
+			t.Errorf("queueManagerDataDir(%v) - expected %v, got %v", table.in, table.out, s)
+		}
+	}
+}
+
+func appendFile(t *testing.T, path string, data string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, err = f.WriteString(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitForLine(t *testing.T, lines chan string, expected string) {
+	select {
+	case l := <-lines:
+		if l != expected {
+			t.Errorf("Expected line %v, got %v", expected, l)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for line %v", expected)
+	}
+}
+
+// TestMirrorLogs checks that only new lines are mirrored, and that mirroring
+// continues after the log file is rotated
+func TestMirrorLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log1 := filepath.Join(dir, "AMQERR01.LOG")
+	log2 := filepath.Join(dir, "AMQERR02.LOG")
+	appendFile(t, log1, "old entry\n")
+	stateFile := filepath.Join(dir, "mirror.json")
+	offsets := logOffsets([]string{log1}, stateFile)
+	appendFile(t, log1, "startup entry\n")
+
+	lines := make(chan string, 10)
+	m, err := mirrorLogs(offsets, stateFile, channelOutput(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForLine(t, lines, "startup entry")
+	appendFile(t, log1, "running entry\n")
+	waitForLine(t, lines, "running entry")
+
+	// Rotate the log file in the same way as MQ
+	err = os.Rename(log1, log2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, log1, "rotated entry\n")
+	waitForLine(t, lines, "rotated entry")
+	m.stop()
+
+	// The saved position should be in the new file
+	positions, err := loadLogPositions(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(log1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := logPosition{Inode: fileInode(fi), Offset: int64(len("rotated entry\n"))}
+	if positions[log1] != expected {
+		t.Errorf("Expected saved position %+v, got %+v", expected, positions[log1])
+	}
+}
+
+// channelOutput returns an output function for mirrorLogs, which sends each
+// line to a channel
+func channelOutput(lines chan string) func() func(string) {
+	return func() func(string) {
+		return func(msg string) {
+			lines <- msg
+		}
+	}
+}
+
+// TestMirrorLogsResume checks that a new container mirrors the entries
+// written after the previous one stopped mirroring, and starts from the
+// beginning of a log file which has been rotated in between
+func TestMirrorLogsResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log1 := filepath.Join(dir, "AMQERR01.LOG")
+	log2 := filepath.Join(dir, "AMQERR02.LOG")
+	stateFile := filepath.Join(dir, "mirror.json")
+	appendFile(t, log1, "old entry\n")
+	lines := make(chan string, 10)
+	m, err := mirrorLogs(logOffsets([]string{log1}, stateFile), stateFile, channelOutput(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, log1, "mirrored entry\n")
+	waitForLine(t, lines, "mirrored entry")
+	m.stop()
+
+	// Written after the first container stopped mirroring
+	appendFile(t, log1, "unmirrored entry\n")
+	m, err = mirrorLogs(logOffsets([]string{log1}, stateFile), stateFile, channelOutput(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForLine(t, lines, "unmirrored entry")
+	m.stop()
+
+	// Rotate the log file between containers, with a new file which is
+	// longer than the saved offset
+	err = os.Rename(log1, log2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, log1, "a long entry in the new log file, written after rotation\n")
+	offsets := logOffsets([]string{log1}, stateFile)
+	if offsets[log1] != 0 {
+		t.Errorf("Expected offset 0 after rotation, got %v", offsets[log1])
+	}
+	m, err = mirrorLogs(offsets, stateFile, channelOutput(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForLine(t, lines, "a long entry in the new log file, written after rotation")
+	m.stop()
+}
+
+var errorLogFilterTests = []struct {
+	severity string
+	exclude  string
+	id       string
+	sev      errorlog.Severity
+	included bool
+}{
+	{"", "", "AMQ5051I", errorlog.SeverityInformation, true},
+	{"W", "", "AMQ5051I", errorlog.SeverityInformation, false},
+	{"W", "", "AMQ9209E", errorlog.SeverityError, true},
+	{"E", "", "AMQ8003", errorlog.SeverityUnknown, true},
+	{"", "AMQ5051,AMQ5052", "AMQ5051I", errorlog.SeverityInformation, false},
+	{"", "amq5051i", "AMQ5051I", errorlog.SeverityInformation, false},
+	{"", "AMQ5051", "AMQ5052I", errorlog.SeverityInformation, true},
+}
+
+func TestErrorLogFilter(t *testing.T) {
+	defer os.Unsetenv("MQ_ERRORLOG_SEVERITY")
+	defer os.Unsetenv("MQ_ERRORLOG_EXCLUDE")
+	for _, table := range errorLogFilterTests {
+		os.Setenv("MQ_ERRORLOG_SEVERITY", table.severity)
+		os.Setenv("MQ_ERRORLOG_EXCLUDE", table.exclude)
+		f, err := newErrorLogFilterFromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := &errorlog.Entry{MessageID: table.id, Severity: table.sev}
+		if f.include(e) != table.included {
+			t.Errorf("include(%v) with MQ_ERRORLOG_SEVERITY=%v and MQ_ERRORLOG_EXCLUDE=%v - expected %v", table.id, table.severity, table.exclude, table.included)
+		}
+	}
+}
+
+const testErrorLog = `11/06/2017 10:21:45 AM - Process(1234.1) User(mqm) Program(amqzxma0)
+                    Host(host1) Installation(Installation1)
+                    VRMF(9.0.4.0) QMgr(QM1)
+
+AMQ5051I: The queue manager task 'LOGGER-IO' has started.
+
+EXPLANATION:
+The critical utility task manager has started the LOGGER-IO task.
+ACTION:
+None.
+----- amqzmuc0.c : 1234 -------------------------------------------------------
+11/06/2017 10:21:46 AM - Process(1234.7) User(mqm) Program(amqzxma0)
+                    Host(host1) Installation(Installation1)
+                    VRMF(9.0.4.0) QMgr(QM1)
+
+AMQ6119S: An internal IBM MQ error has occurred (Failed to open log file)
+
+EXPLANATION:
+MQ detected an unexpected error when calling the operating system.
+ACTION:
+Save any generated output files.
+----- amqxfdcp.c : 987 --------------------------------------------------------
+`
+
+// TestErrorLogOutput checks that the full text of each included entry is
+// logged
+func TestErrorLogOutput(t *testing.T) {
+	var buf bytes.Buffer
+	log, _ = logger.NewLogger(&buf, false, logger.FormatBasic, "test")
+	f := &errorLogFilter{minSeverity: errorlog.SeveritySevere, exclude: map[string]bool{}}
+	output := errorLogOutput(f)()
+	for _, line := range strings.Split(testErrorLog, "\n") {
+		output(line)
+	}
+	out := buf.String()
+	if strings.Contains(out, "AMQ5051I") {
+		t.Errorf("errorLogOutput() - expected AMQ5051I to be filtered out, got %q", out)
+	}
+	for _, s := range []string{"Program(amqzxma0)", "AMQ6119S", "EXPLANATION:", "operating system", "ACTION:", "Save any generated output files", "amqxfdcp.c : 987"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("errorLogOutput() - expected output to contain %q, got %q", s, out)
+		}
+	}
+}
+
+// TestMirrorLogsSave checks that the positions are saved periodically, rather
+// than for every line, and when mirroring stops
+func TestMirrorLogsSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(d time.Duration) { mirrorSaveInterval = d }(mirrorSaveInterval)
+	mirrorSaveInterval = time.Hour
+	log1 := filepath.Join(dir, "AMQERR01.LOG")
+	stateFile := filepath.Join(dir, "mirror.json")
+	lines := make(chan string, 10)
+	m, err := mirrorLogs(logOffsets([]string{log1}, stateFile), stateFile, channelOutput(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, log1, "first entry\n")
+	waitForLine(t, lines, "first entry")
+	_, err = os.Stat(stateFile)
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected the position not to be saved for each line, got %v", err)
+	}
+	m.stop()
+	positions, err := loadLogPositions(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if positions[log1].Offset != int64(len("first entry\n")) {
+		t.Errorf("Expected the position to be saved when mirroring stops, got %+v", positions[log1])
+	}
+}
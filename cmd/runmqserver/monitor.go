@@ -0,0 +1,55 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/command"
+	"github.com/ibm-messaging/mq-container/internal/display"
+)
+
+// monitorInterval is how often the status of the queue manager is checked
+const monitorInterval = 5 * time.Second
+
+// parseQueueManagerStatus parses the status from the output of "dspmq -n",
+// for example "QMNAME(qm1) STATUS(RUNNING)"
+func parseQueueManagerStatus(out string) (string, error) {
+	status, ok := display.ParseAttributes(out)["STATUS"]
+	if !ok {
+		return "", fmt.Errorf("Unable to find queue manager status in: %v", strings.TrimSpace(out))
+	}
+	return status, nil
+}
+
+// queueManagerStatus returns the status of the queue manager, as reported by
+// dspmq, for example "RUNNING" or "ENDED UNEXPECTEDLY"
+func queueManagerStatus(name string) (string, error) {
+	out, rc, err := command.Run("dspmq", "-n", "-m", name)
+	if err != nil {
+		return "", fmt.Errorf("dspmq returned %v: %v", rc, strings.TrimSpace(out))
+	}
+	return parseQueueManagerStatus(out)
+}
+
+// queueManagerEnded returns true if the status indicates that the queue
+// manager has stopped.  Transient states, such as "QUIESCING", are treated
+// as running, because the queue manager might still be doing work.
+func queueManagerEnded(status string) bool {
+	return strings.HasPrefix(status, "ENDED")
+}
@@ -0,0 +1,50 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"testing"
+)
+
+var statusTests = []struct {
+	in    string
+	out   string
+	ended bool
+}{
+	{"QMNAME(qm1)                                               STATUS(RUNNING)\n", "RUNNING", false},
+	{"QMNAME(qm1)                                               STATUS(QUIESCING)\n", "QUIESCING", false},
+	{"QMNAME(qm1)                                               STATUS(ENDED NORMALLY)\n", "ENDED NORMALLY", true},
+	{"QMNAME(qm1)                                               STATUS(ENDED UNEXPECTEDLY)\n", "ENDED UNEXPECTEDLY", true},
+}
+
+func TestParseQueueManagerStatus(t *testing.T) {
+	for _, table := range statusTests {
+		s, err := parseQueueManagerStatus(table.in)
+		if err != nil {
+			t.Error(err)
+		}
+		if s != table.out {
+			t.Errorf("parseQueueManagerStatus(%v) - expected %v, got %v", table.in, table.out, s)
+		}
+		if queueManagerEnded(s) != table.ended {
+			t.Errorf("queueManagerEnded(%v) - expected %v", s, table.ended)
+		}
+	}
+	_, err := parseQueueManagerStatus("AMQ7048: The queue manager name is either not valid or not known.")
+	if err == nil {
+		t.Error("Expected error when status is missing")
+	}
+}
@@ -16,8 +16,8 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"io/ioutil"
-	"log"
 	"os/user"
 	"runtime"
 	"strings"
@@ -86,8 +86,9 @@ func readProc(filename string) (value string, err error) {
 func readMounts() error {
 	all, err := readProc("/proc/mounts")
 	if err != nil {
-		log.Println("Error: Couldn't read /proc/mounts")
-		return err
+		// Not fatal, as the file system type can't be checked
+		log.Error("Couldn't read /proc/mounts")
+		return nil
 	}
 	lines := strings.Split(all, "\n")
 	detected := false
@@ -104,48 +105,48 @@ func readMounts() error {
 	if !detected {
 		log.Println("No volume detected. Persistent messages may be lost")
 	} else {
-		checkFS("/mnt/mqm")
+		return checkFS("/mnt/mqm")
 	}
 	return nil
 }
 
-func checkFS(path string) {
+func checkFS(path string) error {
 	statfs := &unix.Statfs_t{}
 	err := unix.Statfs(path, statfs)
 	if err != nil {
-		log.Println(err)
-		return
+		log.Error(err)
+		return nil
 	}
 	t := fsTypes[statfs.Type]
 	switch t {
 	case "aufs", "overlayfs", "tmpfs":
-		log.Fatalf("Error: %v uses unsupported filesystem type %v", path, t)
+		return fmt.Errorf("%v uses unsupported filesystem type %v", path, t)
 	default:
 		log.Printf("Detected %v has filesystem type '%v'", path, t)
 	}
+	return nil
 }
 
-func logConfig() {
+func logConfig() error {
 	log.Printf("CPU architecture: %v", runtime.GOARCH)
 	if runtime.GOOS == "linux" {
 		var err error
 		osr, err := readProc("/proc/sys/kernel/osrelease")
 		if err != nil {
-			log.Println(err)
+			log.Error(err)
 		} else {
 			log.Printf("Linux kernel version: %v", osr)
 		}
 		logBaseImage()
 		fileMax, err := readProc("/proc/sys/fs/file-max")
 		if err != nil {
-			log.Println(err)
+			log.Error(err)
 		} else {
 			log.Printf("Maximum file handles: %v", fileMax)
 		}
 		logUser()
 		logCapabilities()
-		readMounts()
-	} else {
-		log.Fatalf("Unsupported platform: %v", runtime.GOOS)
+		return readMounts()
 	}
+	return fmt.Errorf("Unsupported platform: %v", runtime.GOOS)
 }
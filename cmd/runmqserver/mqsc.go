@@ -0,0 +1,381 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/internal/listener"
+	"github.com/ibm-messaging/mq-container/internal/mqsc"
+	"github.com/ibm-messaging/mq-container/internal/qmconfig"
+	"github.com/ibm-messaging/mq-container/internal/templates"
+)
+
+// mqscData is the data available to MQSC templates, for example
+// {{ .QueueManager }} or {{ .Env.APP_QUEUE }}
+type mqscData struct {
+	QueueManager string
+	Hostname     string
+	Env          map[string]string
+}
+
+// newMQSCData returns the template data for the specified queue manager,
+// using the current environment
+func newMQSCData(qmgr string) (*mqscData, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return &mqscData{
+		QueueManager: qmgr,
+		Hostname:     hostname,
+		Env:          templates.Environ(os.Environ()),
+	}, nil
+}
+
+// renderMQSC renders an MQSC file as a Go text template
+func renderMQSC(filename string, text string, data *mqscData) (string, error) {
+	t, err := templates.New(filename, os.Getenv).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing MQSC template: %v", err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("Error rendering MQSC template: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// Values for the MQ_MQSC_ERROR_ACTION environment variable
+const (
+	mqscErrorAbort string = "abort"
+	mqscErrorWarn  string = "warn"
+)
+
+// Values for the MQ_CONFIG_MODE environment variable
+const (
+	configModeReplace   string = "replace"
+	configModeReconcile string = "reconcile"
+)
+
+// configPolicy controls how the configuration in /etc/mqm is applied
+type configPolicy struct {
+	// errorAction is the action to take if any MQSC commands fail
+	errorAction string
+	// reconcile is true if the declarative configuration should be compared
+	// with the existing objects, rather than redefining them all
+	reconcile bool
+	// prune is true if objects which have been removed from the declarative
+	// configuration should be deleted
+	prune bool
+	// listeners are the listeners set in MQ_LISTENER_PORTS, or nil if the
+	// default listener created by crtmqm is used
+	listeners []listener.Listener
+}
+
+// configPolicyFromEnv reads the MQ_MQSC_ERROR_ACTION, MQ_CONFIG_MODE,
+// MQ_CONFIG_PRUNE and MQ_LISTENER_PORTS environment variables
+func configPolicyFromEnv() (*configPolicy, error) {
+	p := &configPolicy{errorAction: mqscErrorAbort}
+	s := strings.ToLower(os.Getenv("MQ_MQSC_ERROR_ACTION"))
+	switch s {
+	case "":
+	case mqscErrorAbort, mqscErrorWarn:
+		p.errorAction = s
+	default:
+		return nil, fmt.Errorf("Invalid value for MQ_MQSC_ERROR_ACTION: %v", s)
+	}
+	s = strings.ToLower(os.Getenv("MQ_CONFIG_MODE"))
+	switch s {
+	case "", configModeReplace:
+	case configModeReconcile:
+		p.reconcile = true
+	default:
+		return nil, fmt.Errorf("Invalid value for MQ_CONFIG_MODE: %v", s)
+	}
+	s = strings.ToLower(os.Getenv("MQ_CONFIG_PRUNE"))
+	p.prune = s == "true" || s == "1"
+	if p.prune && !p.reconcile {
+		return nil, fmt.Errorf("MQ_CONFIG_PRUNE requires MQ_CONFIG_MODE=%v", configModeReconcile)
+	}
+	listeners, err := listener.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	p.listeners = listeners
+	return p, nil
+}
+
+// runMQSCFile runs the commands from an MQSC file, and logs each command
+// which failed, along with its line number in the file.  The number of
+// failed commands is returned.
+func runMQSCFile(filename string, script string) (int, error) {
+	return runMQSCScript(newMQSCScript(filename, script))
+}
+
+// runMQSCScript runs the commands from an MQSC script, and logs each command
+// which failed, along with the file and line number it came from.  The
+// number of failed commands is returned.
+func runMQSCScript(s *mqscScript) (int, error) {
+	filename := s.path
+	cmd := exec.Command("runmqsc")
+	cmd.Stdin = strings.NewReader(s.text)
+	// Run the command and wait for completion
+	out, err := cmd.CombinedOutput()
+	// Print the runmqsc output, adding tab characters to make it more readable as part of the log
+	log.Printf("Output for \"runmqsc\" with %v:\n\t%v", filename, strings.Replace(string(out), "\n", "\n\t", -1))
+	o := mqsc.ParseOutput(string(out))
+	if !o.Summary {
+		// runmqsc didn't run the commands, for example because it couldn't
+		// connect to the queue manager
+		for _, m := range o.Messages {
+			log.Errorf("%v: %v: %v", filename, m.ID, m.Text)
+		}
+		if err == nil {
+			err = errors.New("no summary from runmqsc")
+		}
+		return 0, fmt.Errorf("Error running MQSC file %v: %v", filename, err)
+	}
+	commands := mqsc.Split(s.text)
+	ran := make(map[string]int)
+	failures := make(map[string]int)
+	failed := 0
+	for _, r := range o.Results {
+		line := 0
+		if r.Number > 0 && r.Number <= len(commands) {
+			line = commands[r.Number-1].Line
+		}
+		file := s.source(line).file
+		ran[file]++
+		if !r.Failed() {
+			continue
+		}
+		failed++
+		failures[file]++
+		for _, m := range r.Messages {
+			if m.Failed() {
+				log.Errorf("%v: %v: %v: %v", s.location(line), strings.Replace(r.Text, "\n", " ", -1), m.ID, m.Text)
+				break
+			}
+		}
+	}
+	if len(s.parts) > 1 {
+		for _, file := range s.parts {
+			log.Printf("Ran %v: %v MQSC commands, %v failed", file, ran[file], failures[file])
+		}
+	}
+	log.Printf("Ran %v: %v MQSC commands read, %v with syntax errors, %v could not be processed", filename, o.Read, o.SyntaxErrors, o.NotProcessed)
+	return failed, nil
+}
+
+// runMQSCScripts runs several scripts in a single runmqsc session, so that
+// runmqsc only needs to connect to the queue manager once.  The number of
+// failed commands is returned.
+func runMQSCScripts(scripts []*mqscScript) (int, error) {
+	switch len(scripts) {
+	case 0:
+		return 0, nil
+	case 1:
+		return runMQSCScript(scripts[0])
+	}
+	s, err := combineScripts(scripts)
+	if err != nil {
+		return 0, err
+	}
+	return runMQSCScript(s)
+}
+
+// declarativeScript returns a script to apply a declarative configuration,
+// either by redefining every object, or by reconciling it with the existing
+// objects.  The number of changes which can't be applied is also returned.
+func declarativeScript(qmgr string, path string, c *qmconfig.Config, policy *configPolicy) (*mqscScript, int, error) {
+	if !policy.reconcile {
+		return newMQSCScript(path+" (generated MQSC)", c.MQSC()), 0, nil
+	}
+	changes, err := c.Plan(func(mqsc string) ([]map[string]string, error) {
+		return displayObjects(qmgr, mqsc)
+	}, policy.prune)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error reconciling configuration in %v: %v", path, err)
+	}
+	s, conflicts := planScript(path, changes)
+	return s, conflicts, nil
+}
+
+// configDir is the directory holding the queue manager configuration
+const configDir string = "/etc/mqm"
+
+// declarativeFiles returns the possible locations of the declarative
+// configuration file in a directory, in order of preference
+func declarativeFiles(dir string) []string {
+	files := make([]string, len(qmconfig.DefaultFiles))
+	for i, f := range qmconfig.DefaultFiles {
+		files[i] = filepath.Join(dir, filepath.Base(f))
+	}
+	return files
+}
+
+// mqscFiles returns the MQSC files in a directory, in the order in which
+// they are applied
+func mqscFiles(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0)
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".mqsc") {
+			paths = append(paths, filepath.Join(dir, file.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// managedListeners returns the names of the listeners which were defined
+// from MQ_LISTENER_PORTS, which are marked with their description
+func managedListeners(objects []map[string]string) []string {
+	names := make([]string, 0, len(objects))
+	for _, o := range objects {
+		if strings.TrimSpace(o["DESCR"]) == listener.Description {
+			names = append(names, strings.TrimSpace(o["LISTENER"]))
+		}
+	}
+	return names
+}
+
+// listenerScript returns a script to configure the listeners set in
+// MQ_LISTENER_PORTS, or to restore the default listener if it isn't set.
+// Listeners which were defined from an earlier value of MQ_LISTENER_PORTS,
+// but are no longer needed, are stopped.  Listeners defined in other ways
+// aren't changed.
+func listenerScript(qmgr string, listeners []listener.Listener) (*mqscScript, error) {
+	objects, err := displayObjects(qmgr, "DISPLAY LISTENER("+listener.NamePattern+") DESCR")
+	if err != nil {
+		return nil, fmt.Errorf("Error displaying listeners: %v", err)
+	}
+	return newMQSCScript("MQ_LISTENER_PORTS (generated MQSC)", listener.MQSC(listeners, managedListeners(objects))), nil
+}
+
+// configureQueueManager applies any pending migrations, and then renders each
+// MQSC file in /etc/mqm (including the base and overlay directories) as a
+// template, and runs the result against the queue manager.  If any commands fail, then an
+// error is returned, unless the action is to warn.
+func configureQueueManager(qmgr string, policy *configPolicy) error {
+	data, err := newMQSCData(qmgr)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	env := os.Getenv("MQ_ENV")
+	envs := []string{}
+	if env != "" {
+		envs = append(envs, env)
+	}
+	files, err := layoutFiles(configDir, envs)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	// Render all the files before running any of them, so that a mistake
+	// doesn't leave the configuration partly applied
+	scripts, err := loadLayout(files, data)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	logLayout(env, scripts)
+
+	// Migrations are applied exactly once, so any failure is always fatal
+	err = applyMigrations(migrationsDir(configDir), migrationStateFile, data)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	failed := 0
+	// Apply the declarative configuration first, so that MQSC files can
+	// refer to the objects it defines
+	path := qmconfig.Find(declarativeFiles(configDir))
+	if path != "" {
+		c, err := qmconfig.Load(path)
+		if err != nil {
+			err = fmt.Errorf("Invalid configuration in %v:\n%v", path, err)
+			log.Error(err)
+			return err
+		}
+		s, n, err := declarativeScript(qmgr, path, c, policy)
+		if err != nil {
+			log.Error(err)
+			if policy.errorAction == mqscErrorAbort {
+				return err
+			}
+		}
+		failed += n
+		if s != nil {
+			scripts = append([]*mqscScript{s}, scripts...)
+		}
+	}
+	s, err := listenerScript(qmgr, policy.listeners)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	scripts = append([]*mqscScript{s}, scripts...)
+	n, err := runMQSCScripts(scripts)
+	if err != nil {
+		log.Error(err)
+		if policy.errorAction == mqscErrorAbort {
+			return err
+		}
+	}
+	failed += n
+	if failed > 0 {
+		err = fmt.Errorf("%v MQSC commands failed", failed)
+		if policy.errorAction == mqscErrorAbort {
+			log.Error(err)
+			return err
+		}
+		log.Printf("Warning: %v", err)
+	}
+	return nil
+}
+
+// renderConfig prints the MQSC generated from a declarative configuration
+// file, without starting a queue manager.  The file can be specified as an
+// argument, otherwise the default locations are used.
+func renderConfig(args []string) error {
+	path := qmconfig.Find(qmconfig.DefaultFiles)
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "" {
+		return fmt.Errorf("No configuration file found in %v", strings.Join(qmconfig.DefaultFiles, ", "))
+	}
+	c, err := qmconfig.Load(path)
+	if err != nil {
+		return fmt.Errorf("Invalid configuration in %v:\n%v", path, err)
+	}
+	fmt.Print(c.MQSC())
+	return nil
+}
@@ -0,0 +1,245 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ibm-messaging/mq-container/internal/logger"
+)
+
+var renderMQSCTests = []struct {
+	in  string
+	out string
+	err bool
+}{
+	{"DEFINE QLOCAL(APP.QUEUE)", "DEFINE QLOCAL(APP.QUEUE)", false},
+	{"DEFINE QLOCAL({{ env \"TEST_MQSC_QUEUE\" }})", "DEFINE QLOCAL(TEST.QUEUE)", false},
+	{"DEFINE QLOCAL({{ .Env.TEST_MQSC_QUEUE }})", "DEFINE QLOCAL(TEST.QUEUE)", false},
+	{"DEFINE QLOCAL('{{ .Env.TEST_MQSC_MISSING }}')", "DEFINE QLOCAL('')", false},
+	{"DEFINE QLOCAL({{ env \"TEST_MQSC_MISSING\" | default \"DEFAULT.QUEUE\" }})", "DEFINE QLOCAL(DEFAULT.QUEUE)", false},
+	{"DEFINE QLOCAL({{ env \"TEST_MQSC_QUEUE\" | default \"DEFAULT.QUEUE\" }})", "DEFINE QLOCAL(TEST.QUEUE)", false},
+	{"DEFINE QLOCAL({{ required \"TEST_MQSC_QUEUE\" }})", "DEFINE QLOCAL(TEST.QUEUE)", false},
+	{"DEFINE QLOCAL({{ required \"TEST_MQSC_MISSING\" }})", "", true},
+	{"DEFINE QLOCAL({{ .QueueManager | lower }}.IN)", "DEFINE QLOCAL(qm1.IN)", false},
+	{"DEFINE CHANNEL({{ upper .Hostname }}) CHLTYPE(SVRCONN)", "DEFINE CHANNEL(MQHOST) CHLTYPE(SVRCONN)", false},
+	{"DEFINE QLOCAL({{ .Unknown }})", "", true},
+	{"DEFINE QLOCAL({{ env }", "", true},
+}
+
+func TestRenderMQSC(t *testing.T) {
+	os.Setenv("TEST_MQSC_QUEUE", "TEST.QUEUE")
+	defer os.Unsetenv("TEST_MQSC_QUEUE")
+	data := &mqscData{
+		QueueManager: "QM1",
+		Hostname:     "mqhost",
+		Env: map[string]string{
+			"TEST_MQSC_QUEUE": "TEST.QUEUE",
+		},
+	}
+	for _, table := range renderMQSCTests {
+		out, err := renderMQSC("test.mqsc", table.in, data)
+		if table.err {
+			if err == nil {
+				t.Errorf("renderMQSC(%v) - expected error, got %v", table.in, out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("renderMQSC(%v) - unexpected error: %v", table.in, err)
+		} else if out != table.out {
+			t.Errorf("renderMQSC(%v) - expected %v, got %v", table.in, table.out, out)
+		}
+	}
+}
+
+var configPolicyTests = []struct {
+	env    map[string]string
+	policy *configPolicy
+}{
+	{map[string]string{}, &configPolicy{errorAction: mqscErrorAbort}},
+	{map[string]string{"MQ_MQSC_ERROR_ACTION": "WARN"}, &configPolicy{errorAction: mqscErrorWarn}},
+	{map[string]string{"MQ_CONFIG_MODE": "reconcile"}, &configPolicy{errorAction: mqscErrorAbort, reconcile: true}},
+	{map[string]string{"MQ_CONFIG_MODE": "reconcile", "MQ_CONFIG_PRUNE": "true"}, &configPolicy{errorAction: mqscErrorAbort, reconcile: true, prune: true}},
+	{map[string]string{"MQ_CONFIG_PRUNE": "true"}, nil},
+	{map[string]string{"MQ_CONFIG_MODE": "merge"}, nil},
+	{map[string]string{"MQ_MQSC_ERROR_ACTION": "ignore"}, nil},
+}
+
+func TestConfigPolicyFromEnv(t *testing.T) {
+	vars := []string{"MQ_MQSC_ERROR_ACTION", "MQ_CONFIG_MODE", "MQ_CONFIG_PRUNE"}
+	for _, table := range configPolicyTests {
+		for _, v := range vars {
+			os.Unsetenv(v)
+		}
+		for k, v := range table.env {
+			os.Setenv(k, v)
+		}
+		p, err := configPolicyFromEnv()
+		if table.policy == nil {
+			if err == nil {
+				t.Errorf("configPolicyFromEnv(%v) - expected error, got %+v", table.env, p)
+			}
+		} else if err != nil {
+			t.Errorf("configPolicyFromEnv(%v) - unexpected error: %v", table.env, err)
+		} else if !reflect.DeepEqual(p, table.policy) {
+			t.Errorf("configPolicyFromEnv(%v) - expected %+v, got %+v", table.env, table.policy, p)
+		}
+	}
+	for _, v := range vars {
+		os.Unsetenv(v)
+	}
+}
+
+// fakeRunMQSC is a script which behaves like runmqsc, after a delay to
+// simulate connecting to the queue manager.  Commands containing "FAIL" fail.
+const fakeRunMQSC string = `#!/bin/sh
+sleep 0.02
+n=0
+while IFS= read -r line; do
+  case "$line" in
+    "*"*|"") continue ;;
+  esac
+  n=$((n+1))
+  printf '%6d : %s\n' "$n" "$line"
+  case "$line" in
+    *FAIL*) echo "AMQ8150E: IBM MQ object already exists." ;;
+    *) echo "AMQ8006I: IBM MQ queue created." ;;
+  esac
+done
+echo "$n MQSC commands read."
+echo "No commands have a syntax error."
+echo "All valid MQSC commands were processed."
+`
+
+// installFakeRunMQSC puts a fake runmqsc command on the PATH, and sends log
+// messages to the returned buffer.  The returned function restores them.
+func installFakeRunMQSC(t testing.TB) (*bytes.Buffer, func()) {
+	dir, err := ioutil.TempDir("", "runmqsc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "runmqsc"), []byte(fakeRunMQSC), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	oldLog := log
+	var buf bytes.Buffer
+	log, err = logger.NewLogger(&buf, false, logger.FormatBasic, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &buf, func() {
+		log = oldLog
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRunMQSCScripts(t *testing.T) {
+	buf, restore := installFakeRunMQSC(t)
+	defer restore()
+	scripts := []*mqscScript{
+		newMQSCScript("a.mqsc", "DEFINE QLOCAL(A)\nEND\n"),
+		newMQSCScript("b.mqsc", "* Comment\nDEFINE QLOCAL(B)\nDEFINE QLOCAL(FAIL)\n"),
+	}
+	failed, err := runMQSCScripts(scripts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed != 1 {
+		t.Errorf("runMQSCScripts() - expected 1 failure, got %v", failed)
+	}
+	for _, s := range []string{"b.mqsc:3: DEFINE QLOCAL(FAIL): AMQ8150E", "Ran a.mqsc: 1 MQSC commands, 0 failed", "Ran b.mqsc: 2 MQSC commands, 1 failed"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("runMQSCScripts() - expected log to contain %q, got %v", s, buf.String())
+		}
+	}
+}
+
+func TestCombineScriptsContinued(t *testing.T) {
+	scripts := []*mqscScript{
+		newMQSCScript("a.mqsc", "DEFINE QLOCAL(A) +\n"),
+		newMQSCScript("b.mqsc", "DEFINE QLOCAL(B)\n"),
+	}
+	_, err := combineScripts(scripts)
+	if err == nil {
+		t.Error("combineScripts() - expected error")
+	}
+}
+
+// benchmarkScripts returns a number of small MQSC scripts
+func benchmarkScripts(n int) []*mqscScript {
+	scripts := make([]*mqscScript, n)
+	for i := range scripts {
+		scripts[i] = newMQSCScript(fmt.Sprintf("%03d.mqsc", i), fmt.Sprintf("DEFINE QLOCAL(Q%v) REPLACE\n", i))
+	}
+	return scripts
+}
+
+// BenchmarkRunMQSCPerFile runs 30 files, with one runmqsc process for each
+func BenchmarkRunMQSCPerFile(b *testing.B) {
+	_, restore := installFakeRunMQSC(b)
+	defer restore()
+	scripts := benchmarkScripts(30)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, s := range scripts {
+			_, err := runMQSCScript(s)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkRunMQSCSingleSession runs 30 files in a single runmqsc process
+func BenchmarkRunMQSCSingleSession(b *testing.B) {
+	_, restore := installFakeRunMQSC(b)
+	defer restore()
+	scripts := benchmarkScripts(30)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := runMQSCScripts(scripts)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestManagedListeners checks that only the listeners defined from
+// MQ_LISTENER_PORTS are changed, and not other listeners with similar names
+func TestManagedListeners(t *testing.T) {
+	objects := []map[string]string{
+		{"LISTENER": "LISTENER.TCP.1415", "DESCR": "Defined from MQ_LISTENER_PORTS"},
+		{"LISTENER": "LISTENER.TCP.APP", "DESCR": "Application listener"},
+		{"LISTENER": "LISTENER.TCP.1416", "DESCR": ""},
+	}
+	names := managedListeners(objects)
+	expected := []string{"LISTENER.TCP.1415"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("managedListeners() - expected %v, got %v", expected, names)
+	}
+}
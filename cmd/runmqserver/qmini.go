@@ -0,0 +1,210 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/internal/mqini"
+)
+
+// qmINIEnvPrefix is the prefix for environment variables which set a key in
+// qm.ini, such as MQ_INI_CHANNELS_MAXCHANNELS
+const qmINIEnvPrefix string = "MQ_INI_"
+
+// qmINIStanzas are the stanzas which can be used in qm.ini, in the case used
+// by MQ
+var qmINIStanzas = []string{
+	"AccessMode",
+	"ApiExitLocal",
+	"AutoCluster",
+	"AutoConfig",
+	"Broker",
+	"Channels",
+	"DiagnosticMessages",
+	"ExitPath",
+	"ExitPropertiesLocal",
+	"Filesystem",
+	"InstanceData",
+	"Log",
+	"LU62",
+	"NETBIOS",
+	"RestrictedMode",
+	"Security",
+	"Service",
+	"ServiceComponent",
+	"SPX",
+	"SSL",
+	"Subpool",
+	"TCP",
+	"TuningParameters",
+	"XAResourceManager",
+}
+
+// qmININamedStanzas are the stanzas which can be repeated, and which are
+// identified by their "Name" key
+var qmININamedStanzas = map[string]bool{
+	"ApiExitLocal":      true,
+	"Service":           true,
+	"ServiceComponent":  true,
+	"XAResourceManager": true,
+}
+
+// qmINIStanza returns the name of a qm.ini stanza in the case used by MQ, or
+// false if the stanza isn't valid
+func qmINIStanza(name string) (string, bool) {
+	for _, s := range qmINIStanzas {
+		if strings.EqualFold(s, name) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// qmINIPath returns the path of the qm.ini file for a queue manager
+func qmINIPath(qmgr string) string {
+	return filepath.Join("/var/mqm/qmgrs", queueManagerDataDir(qmgr), "qm.ini")
+}
+
+// qmINIDir returns the directory holding qm.ini fragments
+func qmINIDir(dir string) string {
+	return filepath.Join(dir, "qm.ini.d")
+}
+
+// checkQMINIStanzas checks that the stanzas in an override are valid, and
+// changes their names to the case used by MQ
+func checkQMINIStanzas(source string, f *mqini.File) error {
+	for _, s := range f.Stanzas("") {
+		name, ok := qmINIStanza(s.Name)
+		if !ok {
+			return fmt.Errorf("%v: Invalid qm.ini stanza %v", source, s.Name)
+		}
+		s.Name = name
+		if _, ok := s.Get("Name"); qmININamedStanzas[name] && !ok {
+			return fmt.Errorf("%v: The %v stanza must include a Name key", source, name)
+		}
+	}
+	return nil
+}
+
+// envQMINIOverrides returns the overrides set by environment variables such
+// as MQ_INI_CHANNELS_MAXCHANNELS, in the form "MQ_INI_<stanza>_<key>".
+// Keys which aren't already in qm.ini are added in upper case.
+func envQMINIOverrides(environ []string) (*mqini.File, error) {
+	env := make([]string, 0)
+	for _, e := range environ {
+		if strings.HasPrefix(e, qmINIEnvPrefix) {
+			env = append(env, e)
+		}
+	}
+	sort.Strings(env)
+	f := &mqini.File{}
+	for _, e := range env {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		names := strings.SplitN(strings.TrimPrefix(parts[0], qmINIEnvPrefix), "_", 2)
+		if len(names) != 2 || names[0] == "" || names[1] == "" {
+			return nil, fmt.Errorf("Invalid environment variable %v: expected a name such as %vCHANNELS_MAXCHANNELS", parts[0], qmINIEnvPrefix)
+		}
+		name, ok := qmINIStanza(names[0])
+		if !ok {
+			return nil, fmt.Errorf("%v: Invalid qm.ini stanza %v", parts[0], names[0])
+		}
+		if qmININamedStanzas[name] {
+			return nil, fmt.Errorf("%v: The %v stanza can be repeated, so must be set using a file in %v", parts[0], name, qmINIDir(configDir))
+		}
+		s := f.Stanza(name)
+		if s == nil {
+			s = mqini.NewStanza(name)
+			f.AddStanza(s)
+		}
+		s.Set(names[1], parts[1])
+	}
+	return f, nil
+}
+
+// qmINIOverrides returns the overrides for qm.ini, from the *.ini files in a
+// directory, in alphabetical order, followed by environment variables.
+// Later overrides take precedence.
+func qmINIOverrides(dir string, environ []string) (*mqini.File, error) {
+	overrides := &mqini.File{}
+	files, err := filepath.Glob(filepath.Join(dir, "*.ini"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		f, err := mqini.Load(file)
+		if err != nil {
+			return nil, err
+		}
+		err = checkQMINIStanzas(file, f)
+		if err != nil {
+			return nil, err
+		}
+		overrides.Merge(f)
+	}
+	env, err := envQMINIOverrides(environ)
+	if err != nil {
+		return nil, err
+	}
+	overrides.Merge(env)
+	return overrides, nil
+}
+
+// applyQMINIOverrides merges the overrides into a qm.ini file, and logs the
+// changes made
+func applyQMINIOverrides(path string, overrides *mqini.File) error {
+	if len(overrides.Stanzas("")) == 0 {
+		return nil
+	}
+	f, err := mqini.Load(path)
+	if err != nil {
+		return err
+	}
+	changes := f.Merge(overrides)
+	if len(changes) == 0 {
+		log.Printf("No changes needed to %v", path)
+		return nil
+	}
+	for _, c := range changes {
+		log.Printf("Updating %v: %v", path, c)
+	}
+	return f.Save(path)
+}
+
+// updateQMINI applies the overrides from /etc/mqm/qm.ini.d and the
+// environment to the queue manager's qm.ini.  This needs to happen before the
+// queue manager is started.
+func updateQMINI(qmgr string) error {
+	overrides, err := qmINIOverrides(qmINIDir(configDir), os.Environ())
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	err = applyQMINIOverrides(qmINIPath(qmgr), overrides)
+	if err != nil {
+		log.Errorf("Error updating qm.ini: %v", err)
+		return err
+	}
+	return nil
+}
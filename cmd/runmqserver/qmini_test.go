@@ -0,0 +1,112 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ibm-messaging/mq-container/internal/logger"
+)
+
+const testQMINI = `#* Queue manager configuration *#
+Log:
+   LogPrimaryFiles=3
+   LogBufferPages=0
+Channels:
+   MaxChannels=100
+ApiExitLocal:
+   Name=ExitA
+   Sequence=1
+`
+
+var qmINIOverridesTests = []struct {
+	files   map[string]string
+	environ []string
+	out     string
+	err     string
+}{
+	{nil, nil, testQMINI, ""},
+	{
+		map[string]string{"b.ini": "Log:\n   LogBufferPages=256\n", "a.ini": "log:\n   LogBufferPages=128\n   LogPrimaryFiles=5\n"},
+		[]string{"MQ_INI_LOG_LOGPRIMARYFILES=10", "MQ_INI_TCP_KEEPALIVE=YES", "PATH=/bin"},
+		"#* Queue manager configuration *#\nLog:\n   LogPrimaryFiles=10\n   LogBufferPages=256\nChannels:\n   MaxChannels=100\nApiExitLocal:\n   Name=ExitA\n   Sequence=1\nTCP:\n   KEEPALIVE=YES\n",
+		"",
+	},
+	{
+		map[string]string{"exits.ini": "ApiExitLocal:\n   Name=ExitA\n   Sequence=2\n"},
+		[]string{"MQ_INI_CHANNELS_MAXCHANNELS=200"},
+		"#* Queue manager configuration *#\nLog:\n   LogPrimaryFiles=3\n   LogBufferPages=0\nChannels:\n   MaxChannels=200\nApiExitLocal:\n   Name=ExitA\n   Sequence=2\n",
+		"",
+	},
+	{map[string]string{"a.ini": "Logs:\n   LogBufferPages=256\n"}, nil, "", "Invalid qm.ini stanza Logs"},
+	{map[string]string{"a.ini": "ApiExitLocal:\n   Sequence=2\n"}, nil, "", "must include a Name key"},
+	{nil, []string{"MQ_INI_LOGS_LOGBUFFERPAGES=1"}, "", "Invalid qm.ini stanza LOGS"},
+	{nil, []string{"MQ_INI_LOG=1"}, "", "Invalid environment variable MQ_INI_LOG"},
+	{nil, []string{"MQ_INI_APIEXITLOCAL_SEQUENCE=1"}, "", "must be set using a file"},
+}
+
+func TestQMINIOverrides(t *testing.T) {
+	var buf bytes.Buffer
+	log, _ = logger.NewLogger(&buf, false, logger.FormatBasic, "test")
+	for _, table := range qmINIOverridesTests {
+		dir, err := ioutil.TempDir("", "qmini")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		for name, content := range table.files {
+			err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		overrides, err := qmINIOverrides(dir, table.environ)
+		if table.err != "" {
+			if err == nil || !strings.Contains(err.Error(), table.err) {
+				t.Errorf("qmINIOverrides(%v, %v) - expected error containing %q, got %v", table.files, table.environ, table.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("qmINIOverrides(%v, %v) - unexpected error: %v", table.files, table.environ, err)
+			continue
+		}
+		path := filepath.Join(dir, "qm.ini")
+		err = ioutil.WriteFile(path, []byte(testQMINI), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = applyQMINIOverrides(path, overrides)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != table.out {
+			t.Errorf("applyQMINIOverrides(%v, %v) - expected %q, got %q", table.files, table.environ, table.out, out)
+		}
+	}
+	if !strings.Contains(buf.String(), "Channels: MaxChannels=200 (was 100)") {
+		t.Errorf("applyQMINIOverrides() - expected changes to be logged, got %q", buf.String())
+	}
+}
@@ -0,0 +1,64 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/internal/display"
+	"github.com/ibm-messaging/mq-container/internal/qmconfig"
+)
+
+// displayObjects runs an MQSC DISPLAY command, and returns the attributes of
+// each object displayed
+func displayObjects(qmgr string, mqsc string) ([]map[string]string, error) {
+	objects, err := display.Display(display.RunMQSC(qmgr), mqsc)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make([]map[string]string, len(objects))
+	for i, o := range objects {
+		attrs[i] = o.Attributes
+	}
+	return attrs, nil
+}
+
+// planScript logs the changes planned by reconciling a configuration file,
+// and returns a script to apply them, or nil if there is nothing to apply.
+// Changes which conflict with the existing objects can't be applied, so the
+// number of conflicts is also returned.
+func planScript(path string, changes []qmconfig.Change) (*mqscScript, int) {
+	if len(changes) == 0 {
+		log.Printf("Configuration in %v is up to date", path)
+		return nil, 0
+	}
+	log.Printf("Planned %v changes for configuration in %v", len(changes), path)
+	conflicts := 0
+	mqsc := make([]string, 0, len(changes))
+	for _, c := range changes {
+		if c.Action == qmconfig.ActionConflict {
+			log.Errorf("Unable to change %v (%v), because %v.  Delete the object, or change the configuration.", c.Object, c.Path, strings.Join(c.Diff, ", "))
+			conflicts++
+			continue
+		}
+		log.Printf("  %v", c)
+		mqsc = append(mqsc, c.MQSC)
+	}
+	if len(mqsc) == 0 {
+		return nil, conflicts
+	}
+	return newMQSCScript(path+" (reconcile)", strings.Join(mqsc, "\n")+"\n"), conflicts
+}
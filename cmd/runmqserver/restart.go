@@ -0,0 +1,174 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/command"
+	"golang.org/x/sys/unix"
+)
+
+// Values for the MQ_RESTART_POLICY environment variable
+const (
+	restartNever     string = "never"
+	restartOnFailure string = "on-failure"
+	restartAlways    string = "always"
+)
+
+// restartPolicy controls whether a queue manager which has ended is
+// restarted inside the same container
+type restartPolicy struct {
+	policy     string
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	// stablePeriod is how long a restarted queue manager must run for,
+	// before the restart attempts are reset
+	stablePeriod time.Duration
+}
+
+// restartPolicyFromEnv reads the restart policy from the MQ_RESTART_POLICY,
+// MQ_RESTART_MAX_RETRIES and MQ_RESTART_BACKOFF environment variables
+func restartPolicyFromEnv() (*restartPolicy, error) {
+	p := &restartPolicy{
+		policy:       restartNever,
+		maxBackoff:   5 * time.Minute,
+		stablePeriod: 10 * time.Minute,
+	}
+	s, ok := os.LookupEnv("MQ_RESTART_POLICY")
+	if ok && s != "" {
+		p.policy = strings.ToLower(s)
+	}
+	switch p.policy {
+	case restartNever, restartOnFailure, restartAlways:
+	default:
+		return nil, fmt.Errorf("Invalid value for MQ_RESTART_POLICY: %v", s)
+	}
+	var err error
+	p.maxRetries, err = getEnvInt("MQ_RESTART_MAX_RETRIES", 3)
+	if err != nil {
+		return nil, err
+	}
+	backoff, err := getEnvInt("MQ_RESTART_BACKOFF", 10)
+	if err != nil {
+		return nil, err
+	}
+	p.backoff = time.Duration(backoff) * time.Second
+	return p, nil
+}
+
+// shouldRestart returns true if a queue manager which ended with the
+// specified status should be restarted, given the number of restarts which
+// have already been attempted.  A failure is an unexpected end, rather than
+// one caused by a user running endmqm.
+func (p *restartPolicy) shouldRestart(status string, attempts int) bool {
+	if attempts >= p.maxRetries {
+		return false
+	}
+	switch p.policy {
+	case restartAlways:
+		return true
+	case restartOnFailure:
+		return status == "ENDED UNEXPECTEDLY"
+	}
+	return false
+}
+
+// delay returns how long to wait before the specified restart attempt, which
+// doubles with each attempt, up to a maximum
+func (p *restartPolicy) delay(attempt int) time.Duration {
+	d := p.backoff
+	for i := 0; i < attempt && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.maxBackoff {
+		return p.maxBackoff
+	}
+	return d
+}
+
+// stable returns true if a queue manager which has been running for the
+// specified time has recovered, so that any earlier restarts no longer count
+// towards the maximum number of retries
+func (p *restartPolicy) stable(running time.Duration) bool {
+	return running >= p.stablePeriod
+}
+
+// serverProcesses are the queue manager processes which don't start with
+// "amq".  Other runmq commands, such as runmqsc or runmqserver itself, might
+// be being used by an administrator, so aren't included.
+var serverProcesses = map[string]bool{
+	"runmqchi": true,
+	"runmqchl": true,
+	"runmqlsr": true,
+	"runmqtrm": true,
+}
+
+// isQueueManagerProcess returns true if the process name belongs to an MQ
+// queue manager process, such as "amqzxma0" or "runmqchi"
+func isQueueManagerProcess(name string) bool {
+	return strings.HasPrefix(name, "amq") || serverProcesses[name]
+}
+
+// killQueueManagerProcesses sends SIGKILL to any queue manager processes
+// which are still running, and returns the number of processes killed
+func killQueueManagerProcesses() int {
+	dirs, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		log.Error(err)
+		return 0
+	}
+	killed := 0
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(dir.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		comm, err := readProc(filepath.Join("/proc", dir.Name(), "comm"))
+		if err != nil || !isQueueManagerProcess(comm) {
+			continue
+		}
+		log.Debugf("Killing process %v (%v)", pid, comm)
+		err = unix.Kill(pid, unix.SIGKILL)
+		if err == nil {
+			killed++
+		}
+	}
+	return killed
+}
+
+// cleanupQueueManager removes anything left behind by a queue manager which
+// has ended unexpectedly, so that it can be started again
+func cleanupQueueManager(name string) {
+	killed := killQueueManagerProcesses()
+	if killed > 0 {
+		log.Printf("Killed %v leftover queue manager processes", killed)
+		// Give the processes time to end, then reap them
+		time.Sleep(time.Second)
+		reapZombies()
+	}
+	out, rc, err := command.Run("/opt/mqm/bin/amqiclen", "-x", "-m", name)
+	if err != nil {
+		log.Printf("Warning: unable to clean up IPC resources (rc=%v): %v", rc, strings.TrimSpace(out))
+	}
+}
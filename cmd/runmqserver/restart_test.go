@@ -0,0 +1,108 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+var shouldRestartTests = []struct {
+	policy   string
+	status   string
+	attempts int
+	restart  bool
+}{
+	{"", "ENDED UNEXPECTEDLY", 0, false},
+	{"never", "ENDED UNEXPECTEDLY", 0, false},
+	{"on-failure", "ENDED UNEXPECTEDLY", 0, true},
+	{"on-failure", "ENDED UNEXPECTEDLY", 3, false},
+	{"on-failure", "ENDED NORMALLY", 0, false},
+	{"always", "ENDED NORMALLY", 2, true},
+	{"ALWAYS", "ENDED IMMEDIATELY", 0, true},
+}
+
+func TestShouldRestart(t *testing.T) {
+	defer os.Unsetenv("MQ_RESTART_POLICY")
+	for _, table := range shouldRestartTests {
+		os.Setenv("MQ_RESTART_POLICY", table.policy)
+		p, err := restartPolicyFromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := p.shouldRestart(table.status, table.attempts)
+		if r != table.restart {
+			t.Errorf("shouldRestart(%v, %v) with policy %v - expected %v, got %v", table.status, table.attempts, table.policy, table.restart, r)
+		}
+	}
+}
+
+func TestRestartPolicyInvalid(t *testing.T) {
+	defer os.Unsetenv("MQ_RESTART_POLICY")
+	defer os.Unsetenv("MQ_RESTART_MAX_RETRIES")
+	os.Setenv("MQ_RESTART_POLICY", "sometimes")
+	_, err := restartPolicyFromEnv()
+	if err == nil {
+		t.Error("Expected error for invalid MQ_RESTART_POLICY")
+	}
+	os.Setenv("MQ_RESTART_POLICY", "always")
+	os.Setenv("MQ_RESTART_MAX_RETRIES", "-1")
+	_, err = restartPolicyFromEnv()
+	if err == nil {
+		t.Error("Expected error for invalid MQ_RESTART_MAX_RETRIES")
+	}
+}
+
+func TestRestartDelay(t *testing.T) {
+	p := &restartPolicy{backoff: 10 * time.Second, maxBackoff: time.Minute}
+	expected := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, d := range expected {
+		if p.delay(i) != d {
+			t.Errorf("delay(%v) - expected %v, got %v", i, d, p.delay(i))
+		}
+	}
+}
+
+func TestRestartStable(t *testing.T) {
+	p := &restartPolicy{stablePeriod: 10 * time.Minute}
+	if p.stable(time.Minute) {
+		t.Error("stable(1m) - expected false")
+	}
+	if !p.stable(10 * time.Minute) {
+		t.Error("stable(10m) - expected true")
+	}
+}
+
+var processNameTests = []struct {
+	in  string
+	out bool
+}{
+	{"amqzxma0", true},
+	{"runmqchi", true},
+	{"runmqlsr", true},
+	{"runmqserver", false},
+	{"runmqsc", false},
+	{"bash", false},
+}
+
+func TestIsQueueManagerProcess(t *testing.T) {
+	for _, table := range processNameTests {
+		if isQueueManagerProcess(table.in) != table.out {
+			t.Errorf("isQueueManagerProcess(%v) - expected %v", table.in, table.out)
+		}
+	}
+}
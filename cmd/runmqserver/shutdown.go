@@ -0,0 +1,128 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/command"
+)
+
+// shutdownStep is one step in the escalating shutdown of a queue manager
+type shutdownStep struct {
+	name string
+	// flag is the option passed to endmqm, which should make endmqm wait
+	// until the queue manager has ended
+	flag    string
+	timeout time.Duration
+}
+
+// shutdownPolicy controls how the queue manager is stopped
+type shutdownPolicy struct {
+	// drain is true if applications should be given a chance to disconnect
+	// before the queue manager is stopped
+	drain        bool
+	drainTimeout time.Duration
+	steps        []shutdownStep
+}
+
+// shutdownPolicyFromEnv returns the policy used to stop the queue manager.
+// By default, the timeout for each step is a share of MQ_GRACE_PERIOD, which
+// should match the time allowed by the container orchestrator (for example
+// the Kubernetes terminationGracePeriodSeconds).  Some time is left over, to
+// allow any remaining processes to be killed.  The timeout for each step can
+// be set individually, in seconds.  Draining is enabled with
+// MQ_DRAIN_ON_SHUTDOWN, and happens before the first step.  The time spent
+// draining is taken from the grace period, and is limited to a third of it,
+// so that the remaining steps still have time to complete.
+func shutdownPolicyFromEnv() (*shutdownPolicy, error) {
+	grace, err := getEnvInt("MQ_GRACE_PERIOD", 30)
+	if err != nil {
+		return nil, err
+	}
+	g := time.Duration(grace) * time.Second
+	drainEnv := strings.ToLower(os.Getenv("MQ_DRAIN_ON_SHUTDOWN"))
+	drain := drainEnv == "true" || drainEnv == "1"
+	drainTimeout, err := getEnvInt("MQ_DRAIN_TIMEOUT", 10)
+	if err != nil {
+		return nil, err
+	}
+	d := time.Duration(drainTimeout) * time.Second
+	if d > g/3 {
+		d = g / 3
+	}
+	if drain {
+		g -= d
+	}
+	steps := []shutdownStep{
+		{name: "controlled", flag: "-w", timeout: g * 50 / 100},
+		{name: "immediate", flag: "-i", timeout: g * 25 / 100},
+		{name: "preemptive", flag: "-p", timeout: g * 15 / 100},
+	}
+	for i := range steps {
+		env := "MQ_SHUTDOWN_" + strings.ToUpper(steps[i].name) + "_TIMEOUT"
+		t, err := getEnvInt(env, -1)
+		if err != nil {
+			return nil, err
+		}
+		if t >= 0 {
+			steps[i].timeout = time.Duration(t) * time.Second
+		}
+	}
+	return &shutdownPolicy{
+		drain:        drain,
+		drainTimeout: d,
+		steps:        steps,
+	}, nil
+}
+
+// stopQueueManager stops the queue manager, escalating from a controlled
+// shutdown to an immediate shutdown, then to a preemptive shutdown, if each
+// step doesn't complete in time.  As a last resort, any remaining queue
+// manager processes are killed.
+func stopQueueManager(name string, policy *shutdownPolicy) error {
+	start := time.Now()
+	if policy.drain {
+		drainQueueManager(name, policy.drainTimeout)
+	}
+	log.Println("Stopping queue manager")
+	for i, step := range policy.steps {
+		if i > 0 {
+			log.Printf("Escalating to %v shutdown of queue manager", step.name)
+		}
+		out, rc, err := command.RunWithTimeout(step.timeout, "endmqm", step.flag, name)
+		if err == command.ErrTimeout {
+			log.Errorf("The %v shutdown of the queue manager did not complete within %v", step.name, step.timeout)
+			continue
+		}
+		if err != nil {
+			// The queue manager might have already ended
+			status, serr := queueManagerStatus(name)
+			if serr != nil || !queueManagerEnded(status) {
+				log.Errorf("Error %v during %v shutdown of queue manager: %v", rc, step.name, strings.TrimSpace(out))
+				continue
+			}
+		}
+		log.Printf("Stopped queue manager in %v", time.Since(start))
+		return nil
+	}
+	killed := killQueueManagerProcesses()
+	log.Errorf("Killed %v queue manager processes, after trying to stop the queue manager for %v", killed, time.Since(start))
+	return fmt.Errorf("Unable to stop queue manager %v", name)
+}
@@ -0,0 +1,98 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+var shutdownTests = []struct {
+	grace      string
+	controlled string
+	timeouts   []time.Duration
+}{
+	{"", "", []time.Duration{15 * time.Second, 7500 * time.Millisecond, 4500 * time.Millisecond}},
+	{"60", "", []time.Duration{30 * time.Second, 15 * time.Second, 9 * time.Second}},
+	{"60", "50", []time.Duration{50 * time.Second, 15 * time.Second, 9 * time.Second}},
+}
+
+func TestShutdownPolicyFromEnv(t *testing.T) {
+	defer os.Unsetenv("MQ_GRACE_PERIOD")
+	defer os.Unsetenv("MQ_SHUTDOWN_CONTROLLED_TIMEOUT")
+	for _, table := range shutdownTests {
+		os.Setenv("MQ_GRACE_PERIOD", table.grace)
+		os.Setenv("MQ_SHUTDOWN_CONTROLLED_TIMEOUT", table.controlled)
+		p, err := shutdownPolicyFromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		steps := p.steps
+		if len(steps) != len(table.timeouts) {
+			t.Fatalf("Expected %v steps, got %v", len(table.timeouts), len(steps))
+		}
+		for i, step := range steps {
+			if step.timeout != table.timeouts[i] {
+				t.Errorf("Step %v with MQ_GRACE_PERIOD=%v - expected timeout %v, got %v", step.name, table.grace, table.timeouts[i], step.timeout)
+			}
+		}
+	}
+	os.Setenv("MQ_GRACE_PERIOD", "thirty")
+	_, err := shutdownPolicyFromEnv()
+	if err == nil {
+		t.Error("Expected error for invalid MQ_GRACE_PERIOD")
+	}
+}
+
+var drainShutdownTests = []struct {
+	grace string
+	drain string
+	out   time.Duration
+}{
+	{"", "", 10 * time.Second},
+	{"60", "5", 5 * time.Second},
+	{"30", "60", 10 * time.Second},
+	{"9", "", 3 * time.Second},
+}
+
+// TestShutdownPolicyDrain checks that the time spent draining and stopping
+// the queue manager fits in the grace period
+func TestShutdownPolicyDrain(t *testing.T) {
+	defer os.Unsetenv("MQ_GRACE_PERIOD")
+	defer os.Unsetenv("MQ_DRAIN_ON_SHUTDOWN")
+	defer os.Unsetenv("MQ_DRAIN_TIMEOUT")
+	os.Setenv("MQ_DRAIN_ON_SHUTDOWN", "true")
+	for _, table := range drainShutdownTests {
+		os.Setenv("MQ_GRACE_PERIOD", table.grace)
+		os.Setenv("MQ_DRAIN_TIMEOUT", table.drain)
+		p, err := shutdownPolicyFromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.drainTimeout != table.out {
+			t.Errorf("MQ_GRACE_PERIOD=%v, MQ_DRAIN_TIMEOUT=%v - expected drain timeout %v, got %v", table.grace, table.drain, table.out, p.drainTimeout)
+		}
+		grace, _ := getEnvInt("MQ_GRACE_PERIOD", 30)
+		total := p.drainTimeout
+		for _, step := range p.steps {
+			total += step.timeout
+		}
+		if total > time.Duration(grace)*time.Second {
+			t.Errorf("MQ_GRACE_PERIOD=%v, MQ_DRAIN_TIMEOUT=%v - expected a total of at most the grace period, got %v", table.grace, table.drain, total)
+		}
+	}
+}
@@ -16,55 +16,195 @@ limitations under the License.
 package main
 
 import (
-	"log"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"golang.org/x/sys/unix"
 )
 
 const (
-	startReaping = iota
-	reapNow      = iota
+	startReaping    = iota
+	reapNow         = iota
+	startMonitoring = iota
 )
 
-func signalHandler(qmgr string) chan int {
+// signalHandler handles signals and other jobs for the queue manager.  The
+// returned control channel is used to send jobs.  A value is sent on the
+// returned done channel when runmqserver should exit: nil if the queue
+// manager was stopped by a signal, or an error if the queue manager ended
+// unexpectedly.  The returned stopped channel is closed at the same time, after
+// which no more jobs are received.  The restart policy controls whether the queue manager is
+// restarted if it ends, and the shutdown policy controls how it is stopped.
+// Once the queue manager is running, the reload function is called to
+// re-apply the configuration when a SIGHUP signal is received, or when a
+// value is received on the config channel.
+func signalHandler(qmgr string, restart *restartPolicy, shutdown *shutdownPolicy, reload func() error, config <-chan struct{}) (chan int, chan error, <-chan struct{}) {
 	control := make(chan int)
+	done := make(chan error, 1)
+	stopped := make(chan struct{})
 	// Use separate channels for the signals, to avoid SIGCHLD signals swamping
 	// the buffer, and preventing other signals.
-	stopSignals := make(chan os.Signal)
-	reapSignals := make(chan os.Signal)
+	stopSignals := make(chan os.Signal, 1)
+	reapSignals := make(chan os.Signal, 1)
+	reloadSignals := make(chan os.Signal, 1)
 	signal.Notify(stopSignals, syscall.SIGTERM, syscall.SIGINT)
+	// Handle SIGHUP straight away, because by default it ends the process
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	// The monitor channel is nil until monitoring is started, and while
+	// waiting to restart the queue manager
+	var monitor <-chan time.Time
+	var ticker *time.Ticker
+	var restartTimer <-chan time.Time
+	restarts := 0
+	// restarted is when the queue manager was last restarted, or zero if
+	// there are no restarts to reset
+	var restarted time.Time
+	// exit stops handling signals, and tells the main goroutine to exit
+	exit := func(err error) {
+		signal.Stop(reapSignals)
+		signal.Stop(stopSignals)
+		signal.Stop(reloadSignals)
+		// One final reap
+		reapZombies()
+		if ticker != nil {
+			ticker.Stop()
+		}
+		close(stopped)
+		done <- err
+	}
+	// scheduleRestart returns true if a restart has been scheduled, after a
+	// queue manager has ended with the specified status
+	scheduleRestart := func(status string) bool {
+		if !restart.shouldRestart(status, restarts) {
+			return false
+		}
+		d := restart.delay(restarts)
+		restarts++
+		log.Printf("Restarting queue manager %v in %v (attempt %v of %v)", qmgr, d, restarts, restart.maxRetries)
+		monitor = nil
+		restartTimer = time.After(d)
+		return true
+	}
+	// reloadConfig re-applies the configuration, if the queue manager is
+	// running.  The reload runs in this goroutine, so that zombies aren't
+	// reaped while runmqsc is running.
+	reloadConfig := func(reason string) {
+		if monitor == nil {
+			log.Printf("Ignoring %v, because queue manager %v isn't running", reason, qmgr)
+			return
+		}
+		log.SetPhase(phaseMQSC)
+		log.Printf("Reloading configuration, after %v", reason)
+		start := time.Now()
+		err := reload()
+		if err != nil {
+			log.Errorf("Configuration reload failed after %v: %v", time.Since(start), err)
+		} else {
+			log.Printf("Configuration reloaded successfully in %v", time.Since(start))
+		}
+		log.SetPhase(phaseRunning)
+	}
 	go func() {
 		for {
 			select {
 			case sig := <-stopSignals:
+				log.SetPhase(phaseShutdown)
 				log.Printf("Signal received: %v", sig)
 				signal.Stop(reapSignals)
 				signal.Stop(stopSignals)
-				stopQueueManager(qmgr)
-				// One final reap
-				reapZombies()
-				close(control)
+				signal.Stop(reloadSignals)
+				stopQueueManager(qmgr, shutdown)
+				exit(nil)
 				// End the goroutine
 				return
 			case <-reapSignals:
-				logDebug("Received SIGCHLD signal")
+				log.Debug("Received SIGCHLD signal")
+				reapZombies()
+			case <-reloadSignals:
+				reloadConfig("SIGHUP signal")
+				reapZombies()
+			case <-config:
+				reloadConfig("a change to the configuration files")
 				reapZombies()
+			case <-monitor:
+				status, err := queueManagerStatus(qmgr)
+				if err != nil {
+					log.Debugf("Unable to check queue manager status: %v", err)
+					continue
+				}
+				if !restarted.IsZero() && restart.stable(time.Since(restarted)) {
+					log.Printf("Queue manager %v has been running for %v since it was restarted, so resetting the restart attempts", qmgr, restart.stablePeriod)
+					restarts = 0
+					restarted = time.Time{}
+				}
+				if queueManagerEnded(status) {
+					log.Errorf("Queue manager %v has stopped unexpectedly, with status %v", qmgr, status)
+					if scheduleRestart(status) {
+						continue
+					}
+					log.SetPhase(phaseShutdown)
+					exit(fmt.Errorf("Queue manager %v ended with status %v", qmgr, status))
+					return
+				}
+			case <-restartTimer:
+				restartTimer = nil
+				log.SetPhase(phaseStrmqm)
+				cleanupQueueManager(qmgr)
+				err := startQueueManager()
+				if err != nil {
+					if scheduleRestart("ENDED UNEXPECTEDLY") {
+						continue
+					}
+					log.SetPhase(phaseShutdown)
+					exit(fmt.Errorf("Unable to restart queue manager %v", qmgr))
+					return
+				}
+				log.SetPhase(phaseRunning)
+				restarted = time.Now()
+				monitor = ticker.C
 			case job := <-control:
 				switch {
 				case job == startReaping:
 					// Add SIGCHLD to the list of signals we're listening to
-					logDebug("Listening for SIGCHLD signals")
+					log.Debug("Listening for SIGCHLD signals")
 					signal.Notify(reapSignals, syscall.SIGCHLD)
 				case job == reapNow:
 					reapZombies()
+				case job == startMonitoring:
+					log.Debug("Monitoring queue manager status")
+					ticker = time.NewTicker(monitorInterval)
+					monitor = ticker.C
 				}
 			}
 		}
 	}()
-	return control
+	return control, done, stopped
+}
+
+// sendControl sends a job to the signal handler, and returns true if it was
+// received.  If the signal handler has already stopped (for example, because
+// a signal was received while the queue manager was starting), then it
+// returns false.
+func sendControl(control chan<- int, stopped <-chan struct{}, job int) bool {
+	select {
+	case control <- job:
+		return true
+	case <-stopped:
+		return false
+	}
+}
+
+// isStopped returns true if the signal handler has stopped
+func isStopped(stopped <-chan struct{}) bool {
+	select {
+	case <-stopped:
+		return true
+	default:
+		return false
+	}
 }
 
 // reapZombies reaps any zombie (terminated) processes now.
@@ -77,6 +217,6 @@ func reapZombies() {
 		if pid == 0 || err == unix.ECHILD {
 			return
 		}
-		logDebugf("Reaped PID %v", pid)
+		log.Debugf("Reaped PID %v", pid)
 	}
 }
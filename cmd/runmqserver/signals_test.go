@@ -0,0 +1,53 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSendControlStopped checks that jobs aren't blocked once the signal
+// handler has stopped
+func TestSendControlStopped(t *testing.T) {
+	control := make(chan int)
+	stopped := make(chan struct{})
+	if isStopped(stopped) {
+		t.Error("isStopped() - expected false before the signal handler stops")
+	}
+	go func() {
+		<-control
+		close(stopped)
+	}()
+	if !sendControl(control, stopped, startReaping) {
+		t.Error("sendControl() - expected the job to be received")
+	}
+	result := make(chan bool)
+	go func() {
+		result <- sendControl(control, stopped, startMonitoring)
+	}()
+	select {
+	case ok := <-result:
+		if ok {
+			t.Error("sendControl() - expected false after the signal handler stopped")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sendControl() - blocked after the signal handler stopped")
+	}
+	if !isStopped(stopped) {
+		t.Error("isStopped() - expected true after the signal handler stops")
+	}
+}
@@ -0,0 +1,201 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// configWatchInterval is how often the configuration directory is checked
+// for changes
+const configWatchInterval = 2 * time.Second
+
+// hashConfigDir adds the names and contents of the files in a directory, and
+// its subdirectories, to a hash.  Symbolic links are followed, so that files
+// in a Kubernetes ConfigMap volume are read through the "..data" link, which
+// is swapped atomically when the ConfigMap changes.  Entries starting with
+// ".." are the atomic writer's own files, so are skipped.
+func hashConfigDir(h hash.Hash, dir string, rel string, visited map[string]bool) error {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	if visited[real] {
+		// Avoid loops caused by symbolic links
+		return nil
+	}
+	visited[real] = true
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "..") {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		fi, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// A broken link, or a file which has just been removed
+				continue
+			}
+			return err
+		}
+		if fi.IsDir() {
+			err = hashConfigDir(h, path, filepath.Join(rel, name), visited)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%v\x00%v\x00", filepath.Join(rel, name), len(data))
+		h.Write(data)
+	}
+	return nil
+}
+
+// configFingerprint returns a hash of the contents of a configuration
+// directory, which changes whenever any of the files change
+func configFingerprint(dir string) (string, error) {
+	h := sha256.New()
+	err := hashConfigDir(h, dir, "", make(map[string]bool))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// configWatcher polls a configuration directory for changes.  Once the
+// directory has stopped changing for the debounce period, a value is sent
+// on the changes channel.
+type configWatcher struct {
+	dir      string
+	debounce time.Duration
+	changes  chan struct{}
+	// applied is the fingerprint of the configuration which was last applied
+	applied string
+	// current is the fingerprint from the last poll, and changed is when it
+	// last changed
+	current string
+	changed time.Time
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// newConfigWatcher creates a watcher for the specified directory.  The
+// current contents of the directory are treated as already applied.
+func newConfigWatcher(dir string, debounce time.Duration) *configWatcher {
+	w := &configWatcher{
+		dir:      dir,
+		debounce: debounce,
+		changes:  make(chan struct{}, 1),
+	}
+	fp, err := configFingerprint(dir)
+	if err != nil {
+		log.Errorf("Unable to read configuration in %v: %v", dir, err)
+	}
+	w.applied, w.current = fp, fp
+	return w
+}
+
+// poll checks the directory, and returns true if it has changed since the
+// configuration was last applied, and has then been stable for the debounce
+// period
+func (w *configWatcher) poll(now time.Time) bool {
+	fp, err := configFingerprint(w.dir)
+	if err != nil {
+		// The directory might be part way through being updated
+		log.Debugf("Unable to read configuration in %v: %v", w.dir, err)
+		return false
+	}
+	if fp != w.current {
+		if fp != w.applied {
+			log.Printf("Detected a change to the configuration in %v", w.dir)
+		}
+		w.current = fp
+		w.changed = now
+		return false
+	}
+	if fp == w.applied || now.Sub(w.changed) < w.debounce {
+		return false
+	}
+	w.applied = fp
+	return true
+}
+
+// start starts polling the directory at the specified interval
+func (w *configWatcher) start(interval time.Duration) {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				if w.poll(now) {
+					select {
+					case w.changes <- struct{}{}:
+					default:
+						// A reload is already pending
+					}
+				}
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopWatching stops polling the directory
+func (w *configWatcher) stopWatching() {
+	close(w.stop)
+	<-w.done
+}
+
+// configWatcherFromEnv returns a watcher for /etc/mqm if MQ_CONFIG_WATCH is
+// set to true, or nil otherwise.  The debounce period is read from
+// MQ_CONFIG_WATCH_DEBOUNCE, in seconds.
+func configWatcherFromEnv() (*configWatcher, error) {
+	s := strings.ToLower(os.Getenv("MQ_CONFIG_WATCH"))
+	if s != "true" && s != "1" {
+		return nil, nil
+	}
+	debounce, err := getEnvInt("MQ_CONFIG_WATCH_DEBOUNCE", 5)
+	if err != nil {
+		return nil, err
+	}
+	return newConfigWatcher(configDir, time.Duration(debounce)*time.Second), nil
+}
@@ -0,0 +1,130 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ibm-messaging/mq-container/internal/logger"
+)
+
+// writeConfigMap writes files in the same way as the Kubernetes atomic
+// writer: into a new timestamped directory, which the "..data" link is then
+// switched to.  The files in the volume are links through "..data".
+func writeConfigMap(t *testing.T, dir string, version string, files map[string]string) {
+	data := filepath.Join(dir, "..2017_"+version)
+	err := os.Mkdir(data, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(data, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			err = os.Symlink(filepath.Join("..data", name), link)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	tmp := filepath.Join(dir, "..data_tmp")
+	err = os.Symlink(filepath.Base(data), tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Rename(tmp, filepath.Join(dir, "..data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigWatcherConfigMap(t *testing.T) {
+	log, _ = logger.NewLogger(ioutil.Discard, false, logger.FormatBasic, "test")
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeConfigMap(t, dir, "1", map[string]string{"config.mqsc": "DEFINE QLOCAL(A)\n"})
+	w := newConfigWatcher(dir, 5*time.Second)
+	now := time.Now()
+	if w.poll(now) {
+		t.Error("poll() - expected no change before the ConfigMap is updated")
+	}
+	writeConfigMap(t, dir, "2", map[string]string{"config.mqsc": "DEFINE QLOCAL(B)\n"})
+	if w.poll(now.Add(1 * time.Second)) {
+		t.Error("poll() - expected no reload as soon as the change is detected")
+	}
+	if w.poll(now.Add(3 * time.Second)) {
+		t.Error("poll() - expected no reload during the debounce period")
+	}
+	if !w.poll(now.Add(7 * time.Second)) {
+		t.Error("poll() - expected a reload after the debounce period")
+	}
+	if w.poll(now.Add(20 * time.Second)) {
+		t.Error("poll() - expected only one reload for each change")
+	}
+}
+
+func TestConfigWatcherDebounce(t *testing.T) {
+	log, _ = logger.NewLogger(ioutil.Discard, false, logger.FormatBasic, "test")
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.mqsc")
+	err = ioutil.WriteFile(path, []byte("DEFINE QLOCAL(A)\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newConfigWatcher(dir, 5*time.Second)
+	now := time.Now()
+	// Keep changing the file, so that the debounce period keeps restarting
+	for i, s := range []string{"B", "C", "D"} {
+		err = ioutil.WriteFile(path, []byte("DEFINE QLOCAL("+s+")\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.poll(now.Add(time.Duration(i*4) * time.Second)) {
+			t.Errorf("poll() - expected no reload while the file is changing")
+		}
+	}
+	if !w.poll(now.Add(15 * time.Second)) {
+		t.Error("poll() - expected a reload once the file stopped changing")
+	}
+	// Changing the file back to the applied version doesn't need a reload
+	err = ioutil.WriteFile(path, []byte("DEFINE QLOCAL(E)\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.poll(now.Add(16 * time.Second))
+	err = ioutil.WriteFile(path, []byte("DEFINE QLOCAL(D)\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.poll(now.Add(17 * time.Second))
+	if w.poll(now.Add(30 * time.Second)) {
+		t.Error("poll() - expected no reload when the configuration hasn't changed")
+	}
+}
@@ -18,11 +18,17 @@ limitations under the License.
 package command
 
 import (
+	"bytes"
+	"errors"
 	"os/exec"
 	"runtime"
 	"syscall"
+	"time"
 )
 
+// ErrTimeout is returned by RunWithTimeout if the command doesn't complete in time
+var ErrTimeout = errors.New("Command timed out")
+
 // Run runs an OS command.  On Linux it waits for the command to
 // complete and returns the exit status (return code).
 // Do not use this function to run shell built-ins (like "cd"), because
@@ -31,6 +37,11 @@ func Run(name string, arg ...string) (string, int, error) {
 	cmd := exec.Command(name, arg...)
 	// Run the command and wait for completion
 	out, err := cmd.CombinedOutput()
+	return result(out, err)
+}
+
+// result returns the output and exit status of a completed command
+func result(out []byte, err error) (string, int, error) {
 	if err != nil {
 		// Assert that this is an ExitError
 		exiterr, ok := err.(*exec.ExitError)
@@ -45,3 +56,29 @@ func Run(name string, arg ...string) (string, int, error) {
 	}
 	return string(out), 0, nil
 }
+
+// RunWithTimeout runs an OS command, in the same way as Run, but waits no
+// longer than the specified timeout for the command to complete.  If the
+// timeout expires, then ErrTimeout is returned, and the command is left
+// running in the background.
+func RunWithTimeout(timeout time.Duration, name string, arg ...string) (string, int, error) {
+	cmd := exec.Command(name, arg...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Start()
+	if err != nil {
+		return "", -1, err
+	}
+	// Buffered, so that the goroutine can end if the timeout expires first
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err = <-done:
+		return result(out.Bytes(), err)
+	case <-time.After(timeout):
+		return "", -1, ErrTimeout
+	}
+}
@@ -18,6 +18,7 @@ package command
 import (
 	"runtime"
 	"testing"
+	"time"
 )
 
 var commandTests = []struct {
@@ -45,3 +46,22 @@ func TestRun(t *testing.T) {
 		}
 	}
 }
+
+func TestRunWithTimeout(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping tests for package which only works on Linux")
+	}
+	for _, table := range commandTests {
+		_, rc, err := RunWithTimeout(10*time.Second, table.name, table.arg...)
+		if rc != table.rc {
+			t.Errorf("RunWithTimeout(%v,%v) - expected %v, got %v", table.name, table.arg, table.rc, rc)
+		}
+		if rc != 0 && err == nil {
+			t.Errorf("RunWithTimeout(%v,%v) - expected error for non-zero return code (rc=%v)", table.name, table.arg, rc)
+		}
+	}
+	_, _, err := RunWithTimeout(100*time.Millisecond, "sleep", "5")
+	if err != ErrTimeout {
+		t.Errorf("RunWithTimeout(sleep 5) - expected timeout error, got %v", err)
+	}
+}
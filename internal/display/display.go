@@ -0,0 +1,353 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package display contains code to run MQSC DISPLAY commands, and to parse
+// their output into objects
+package display
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Object is a single object from the output of a DISPLAY command, such as a
+// queue, or the status of a channel instance
+type Object struct {
+	// MessageID is the ID of the message which introduced the object, such
+	// as "AMQ8409I"
+	MessageID string
+	// Message is the text of the message, such as "Display Queue details."
+	Message string
+	// Attributes holds the value of each attribute, keyed by name.  Attributes
+	// without a value, such as "CURRENT", have an empty value.
+	Attributes map[string]string
+}
+
+// Has returns true if the object has the specified attribute
+func (o *Object) Has(name string) bool {
+	_, ok := o.Attributes[name]
+	return ok
+}
+
+// Get returns the value of an attribute, with any padding removed, or an
+// empty string if the object doesn't have the attribute
+func (o *Object) Get(name string) string {
+	return strings.TrimSpace(o.Attributes[name])
+}
+
+// Int returns the value of an attribute as an integer
+func (o *Object) Int(name string) (int, error) {
+	v, ok := o.Attributes[name]
+	if !ok {
+		return 0, fmt.Errorf("Attribute %v not found", name)
+	}
+	return strconv.Atoi(strings.TrimSpace(v))
+}
+
+// Bool returns true if the value of an attribute is "YES" or "ENABLED"
+func (o *Object) Bool(name string) bool {
+	switch o.Get(name) {
+	case "YES", "ENABLED":
+		return true
+	}
+	return false
+}
+
+// Error is an error message written by runmqsc, such as
+// "AMQ8118E: IBM MQ queue manager does not exist."
+type Error struct {
+	ID   string
+	Text string
+}
+
+func (e *Error) Error() string {
+	return e.ID + ": " + e.Text
+}
+
+var (
+	messagePattern = regexp.MustCompile(`^(AMQ\d{4}([A-Z])):\s*(.*)$`)
+	echoPattern    = regexp.MustCompile(`^\s*\d*\s+:\s`)
+	nameStart      = regexp.MustCompile(`^[A-Z][A-Z0-9]*(\(| |$)`)
+	valueEnd       = regexp.MustCompile(`^( *$| +[A-Z][A-Z0-9]*(\(| |$))`)
+)
+
+// notFound holds the messages which mean that no objects matched
+var notFound = map[string]bool{
+	// IBM MQ object not found
+	"AMQ8147E": true,
+	// Channel Status not found
+	"AMQ8420I": true,
+}
+
+// parser holds the state while parsing the attributes of an object, which
+// can span several lines
+type parser struct {
+	lines []string
+	line  int
+	pos   int
+}
+
+func (p *parser) current() string {
+	return p.lines[p.line]
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.current()) && p.current()[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// nextLine moves to the start of the next line, and returns false if there
+// are no more lines
+func (p *parser) nextLine() bool {
+	if p.line+1 >= len(p.lines) {
+		return false
+	}
+	p.line++
+	p.pos = 0
+	return true
+}
+
+// wrapped returns true if the next line looks like the continuation of a
+// value which has been wrapped, rather than a new attribute
+func (p *parser) wrapped() bool {
+	if p.line+1 >= len(p.lines) {
+		return false
+	}
+	return !nameStart.MatchString(p.lines[p.line+1])
+}
+
+// quotedValue reads a quoted value, starting at the opening quote.  Quotes
+// in the value are doubled.  If the closing quote isn't followed by the
+// closing parenthesis, then the value isn't a single quoted string, such as
+// "'A','B'", and false is returned.
+func (p *parser) quotedValue() (string, bool) {
+	var buf bytes.Buffer
+	p.pos++
+	for {
+		line := p.current()
+		if p.pos >= len(line) {
+			if !p.nextLine() {
+				return "", false
+			}
+			continue
+		}
+		c := line[p.pos]
+		p.pos++
+		if c != '\'' {
+			buf.WriteByte(c)
+			continue
+		}
+		if p.pos < len(line) && line[p.pos] == '\'' {
+			buf.WriteByte(c)
+			p.pos++
+			continue
+		}
+		if p.pos < len(line) && line[p.pos] == ')' {
+			p.pos++
+			return buf.String(), true
+		}
+		return "", false
+	}
+}
+
+// value reads a value, starting after the opening parenthesis.  The value
+// can be quoted, can contain balanced parentheses, and can be wrapped onto
+// the following lines.
+func (p *parser) value() string {
+	if p.pos < len(p.current()) && p.current()[p.pos] == '\'' {
+		line, pos := p.line, p.pos
+		v, ok := p.quotedValue()
+		if ok {
+			return v
+		}
+		p.line, p.pos = line, pos
+	}
+	var buf bytes.Buffer
+	depth := 1
+	startLine, start := p.line, p.pos
+	for {
+		line := p.current()
+		if p.pos >= len(line) {
+			if p.wrapped() {
+				p.nextLine()
+				continue
+			}
+			if p.line == startLine {
+				// The parentheses aren't balanced, so end the value at the
+				// first closing parenthesis which is followed by another
+				// attribute, or by the end of the line
+				for k := start; k < len(line); k++ {
+					if line[k] == ')' && valueEnd.MatchString(line[k+1:]) {
+						p.pos = k + 1
+						return line[start:k]
+					}
+				}
+			}
+			return strings.TrimSuffix(buf.String(), ")")
+		}
+		c := line[p.pos]
+		p.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return buf.String()
+			}
+		}
+		buf.WriteByte(c)
+	}
+}
+
+// parse parses the attributes from the lines of an object
+func (p *parser) parse(attrs map[string]string) {
+	for {
+		p.skipSpaces()
+		line := p.current()
+		if p.pos >= len(line) {
+			if !p.nextLine() {
+				return
+			}
+			continue
+		}
+		start := p.pos
+		for p.pos < len(line) && line[p.pos] != '(' && line[p.pos] != ' ' {
+			p.pos++
+		}
+		name := line[start:p.pos]
+		if p.pos >= len(line) || line[p.pos] != '(' {
+			// An attribute without a value
+			attrs[name] = ""
+			continue
+		}
+		p.pos++
+		attrs[name] = p.value()
+	}
+}
+
+// ParseAttributes parses attributes in the form used by MQSC DISPLAY
+// commands and dspmq, such as "QMNAME(QM1) STATUS(Running)"
+func ParseAttributes(text string) map[string]string {
+	attrs := make(map[string]string)
+	lines := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	p := &parser{lines: lines}
+	p.parse(attrs)
+	return attrs
+}
+
+// Parse parses the output of runmqsc for DISPLAY commands.  Each object is
+// introduced by an informational message, such as
+// "AMQ8409I: Display Queue details.", and its attributes are indented on the
+// following lines.  Other lines written by runmqsc, such as the echoed
+// commands and the summary, are ignored.  If runmqsc reports an error, then
+// it is returned as an *Error, unless the error means that no objects were
+// found.
+func Parse(out string) ([]*Object, error) {
+	objects := make([]*Object, 0)
+	var current *Object
+	var lines []string
+	end := func() {
+		if current != nil && len(lines) > 0 {
+			p := &parser{lines: lines}
+			p.parse(current.Attributes)
+			objects = append(objects, current)
+		}
+		current = nil
+		lines = nil
+	}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if m := messagePattern.FindStringSubmatch(line); m != nil {
+			end()
+			if notFound[m[1]] {
+				continue
+			}
+			switch m[2] {
+			case "E", "S", "T":
+				return nil, &Error{ID: m[1], Text: m[3]}
+			}
+			current = &Object{MessageID: m[1], Message: m[3], Attributes: make(map[string]string)}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if echoPattern.MatchString(line) || !strings.HasPrefix(line, " ") {
+			// Attributes are always indented, so this is the end of the object
+			end()
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	end()
+	return objects, nil
+}
+
+// Runner runs MQSC commands against a queue manager, and returns the output
+type Runner func(mqsc string) (string, error)
+
+// RunMQSC returns a Runner which uses runmqsc, without echoing the commands
+// in the output
+func RunMQSC(qmgr string) Runner {
+	return func(mqsc string) (string, error) {
+		cmd := exec.Command("runmqsc", "-e", qmgr)
+		cmd.Stdin = strings.NewReader(mqsc)
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err := cmd.Run()
+		return out.String(), err
+	}
+}
+
+// Display runs a DISPLAY command, and returns the objects displayed.  If no
+// objects match, then an empty list is returned.
+func Display(run Runner, mqsc string) ([]*Object, error) {
+	out, err := run(mqsc)
+	objects, perr := Parse(out)
+	if perr != nil {
+		return nil, perr
+	}
+	// runmqsc returns an error if no objects were found
+	if err != nil && len(objects) == 0 && !strings.Contains(out, "not found") {
+		return nil, fmt.Errorf("Error running %v: %v", mqsc, strings.TrimSpace(out))
+	}
+	return objects, nil
+}
+
+// Values returns the unique values of an attribute in a list of objects, in
+// the order they appear
+func Values(objects []*Object, name string) []string {
+	values := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, o := range objects {
+		v := o.Get(name)
+		if o.Has(name) && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,186 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package display
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) string {
+	buf, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+// parseTests lists the fixtures, and the attributes expected for each object.
+// Only the listed attributes are checked.
+var parseTests = []struct {
+	fixture string
+	objects []map[string]string
+	err     string
+}{
+	{"queue.txt", []map[string]string{
+		{"QUEUE": "APP.QUEUE", "TYPE": "QLOCAL", "BOQNAME": " ", "CURDEPTH": "12", "CUSTOM": "RUNMQSERVER(MANAGED)", "DESCR": "Orders (priority)", "HARDENBO": "", "TRIGGER": "", "USAGE": "NORMAL"},
+	}, ""},
+	{"queues_echo.txt", []map[string]string{
+		{"QUEUE": "APP.IN", "CURDEPTH": "0"},
+		{"QUEUE": "APP.OUT", "CURDEPTH": "27"},
+	}, ""},
+	{"chstatus.txt", []map[string]string{
+		{"CHANNEL": "APP.SVRCONN", "CONNAME": "10.1.2.3", "CURRENT": "", "STATUS": "RUNNING"},
+		{"CHANNEL": "APP.SVRCONN", "CONNAME": "fe80::1%eth0", "CURRENT": "", "STATUS": "RUNNING"},
+		{"CHANNEL": "TO.QM2", "CONNAME": "qm2.example.com(1414)", "STATUS": "RETRYING", "SUBSTATE": " ", "XMITQ": "QM2"},
+	}, ""},
+	{"conn.txt", []map[string]string{
+		{"CONN": "5A4B2C1D00010001", "EXTCONN": "414D5143514D31202020202020202020", "TYPE": "CONN", "APPLTAG": "client1", "APPLTYPE": "USER"},
+		{"CONN": "5A4B2C1D00020001", "EXTCONN": "414D5143514D31202020202020202020", "TYPE": "CONN", "APPLTAG": "client2", "APPLTYPE": "USER"},
+		{"CONN": "5A4B2C1D00030001", "EXTCONN": "414D5143514D31202020202020202020", "TYPE": "CONN", "APPLTAG": "runmqsc", "APPLTYPE": "USER"},
+	}, ""},
+	{"chstatus_none.txt", []map[string]string{}, ""},
+	{"not_found.txt", []map[string]string{}, ""},
+	{"qmgr_error.txt", nil, "AMQ8146E"},
+	{"wrapped.txt", []map[string]string{
+		{"TOPIC": "APP.EVENTS", "TOPICSTR": "organisation/department/application/events/orders/created/v1/priority/high/region/europe/country/uk", "DESCR": "Order events", "DURSUB": "ASPARENT"},
+	}, ""},
+	{"quoted.txt", []map[string]string{
+		{"CHLAUTH": "APP.SVRCONN", "DESCR": "It's (not) a problem", "USERLIST": "'nobody','*MQADMIN'", "WARN": "NO"},
+	}, ""},
+	{"unbalanced.txt", []map[string]string{
+		{"QUEUE": "APP.SAD", "DESCR": "Unhappy :-( queue", "MAXDEPTH": "5000", "QDEPTHHI": "80", "PUT": "ENABLED"},
+	}, ""},
+}
+
+func TestParse(t *testing.T) {
+	for _, table := range parseTests {
+		objects, err := Parse(readFixture(t, table.fixture))
+		if table.err != "" {
+			e, ok := err.(*Error)
+			if !ok || e.ID != table.err {
+				t.Errorf("Parse(%v) - expected error %v, got %v", table.fixture, table.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%v) - unexpected error: %v", table.fixture, err)
+			continue
+		}
+		if len(objects) != len(table.objects) {
+			t.Errorf("Parse(%v) - expected %v objects, got %v", table.fixture, len(table.objects), len(objects))
+			continue
+		}
+		for i, expected := range table.objects {
+			for name, value := range expected {
+				v, ok := objects[i].Attributes[name]
+				if !ok || v != value {
+					t.Errorf("Parse(%v) - expected object %v to have %v(%v), got %q (found=%v)", table.fixture, i, name, value, v, ok)
+				}
+			}
+		}
+	}
+}
+
+func TestParseAttributeCount(t *testing.T) {
+	objects, err := Parse(readFixture(t, "queue.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every attribute in the fixture should be found, with nothing extra
+	if len(objects[0].Attributes) != 36 {
+		t.Errorf("Parse() - expected 36 attributes, got %v: %v", len(objects[0].Attributes), objects[0].Attributes)
+	}
+	if objects[0].MessageID != "AMQ8409I" || objects[0].Message != "Display Queue details." {
+		t.Errorf("Parse() - unexpected message %v: %v", objects[0].MessageID, objects[0].Message)
+	}
+}
+
+func TestObject(t *testing.T) {
+	objects, err := Parse(readFixture(t, "queue.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := objects[0]
+	depth, err := o.Int("CURDEPTH")
+	if err != nil || depth != 12 {
+		t.Errorf("Int(CURDEPTH) - expected 12, got %v (%v)", depth, err)
+	}
+	_, err = o.Int("MISSING")
+	if err == nil {
+		t.Errorf("Int(MISSING) - expected error")
+	}
+	if !o.Bool("GET") || !o.Bool("DEFPSIST") || o.Bool("DEFREADA") {
+		t.Errorf("Bool() - unexpected results for GET, DEFPSIST and DEFREADA")
+	}
+	if o.Get("BOQNAME") != "" || !o.Has("BOQNAME") || o.Has("MISSING") {
+		t.Errorf("Get() - unexpected results for BOQNAME and MISSING")
+	}
+}
+
+var parseAttributesTests = []struct {
+	in  string
+	out map[string]string
+}{
+	{"QMNAME(QM1)                                               STATUS(Running)", map[string]string{"QMNAME": "QM1", "STATUS": "Running"}},
+	{"QMNAME(QM1) STATUS(Ended unexpectedly)\n", map[string]string{"QMNAME": "QM1", "STATUS": "Ended unexpectedly"}},
+	{"QMNAME(QM1) STATUS(Running as standby) DEFAULT(yes)", map[string]string{"QMNAME": "QM1", "STATUS": "Running as standby", "DEFAULT": "yes"}},
+	{"", map[string]string{}},
+}
+
+func TestParseAttributes(t *testing.T) {
+	for _, table := range parseAttributesTests {
+		out := ParseAttributes(table.in)
+		if !reflect.DeepEqual(out, table.out) {
+			t.Errorf("ParseAttributes(%q) - expected %v, got %v", table.in, table.out, out)
+		}
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	fixture := readFixture(t, "chstatus.txt")
+	run := func(mqsc string) (string, error) {
+		return fixture, nil
+	}
+	objects, err := Display(run, "DISPLAY CHSTATUS(*)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := Values(objects, "CHANNEL")
+	expected := []string{"APP.SVRCONN", "TO.QM2"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Values() - expected %v, got %v", expected, values)
+	}
+	// runmqsc fails when no channels are found
+	none := readFixture(t, "chstatus_none.txt")
+	run = func(mqsc string) (string, error) {
+		return none, errors.New("exit status 10")
+	}
+	objects, err = Display(run, "DISPLAY CHSTATUS(*)")
+	if err != nil || len(objects) != 0 {
+		t.Errorf("Display() - expected no objects, got %v (%v)", objects, err)
+	}
+	run = func(mqsc string) (string, error) {
+		return "", errors.New("exit status 20")
+	}
+	_, err = Display(run, "DISPLAY CHSTATUS(*)")
+	if err == nil {
+		t.Errorf("Display() - expected error")
+	}
+}
@@ -0,0 +1,296 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package errorlog contains code to parse MQ error log files (AMQERRnn.LOG)
+package errorlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity is the severity of an MQ message, as indicated by the last
+// character of the message ID (for example, the "I" in "AMQ5051I")
+type Severity int
+
+// Message severities, in increasing order.  Older versions of MQ don't
+// include the severity in the message ID, so SeverityUnknown is used.
+const (
+	SeverityUnknown Severity = iota
+	SeverityInformation
+	SeverityWarning
+	SeverityError
+	SeveritySevere
+	SeverityTermination
+)
+
+var severityCodes = map[string]Severity{
+	"I": SeverityInformation,
+	"W": SeverityWarning,
+	"E": SeverityError,
+	"S": SeveritySevere,
+	"T": SeverityTermination,
+}
+
+// String returns the single-character code for the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityInformation:
+		return "I"
+	case SeverityWarning:
+		return "W"
+	case SeverityError:
+		return "E"
+	case SeveritySevere:
+		return "S"
+	case SeverityTermination:
+		return "T"
+	}
+	return "?"
+}
+
+// ParseSeverity parses a severity code, such as "W" or "warning"
+func ParseSeverity(s string) (Severity, error) {
+	if s == "" {
+		return SeverityUnknown, fmt.Errorf("Invalid severity: %v", s)
+	}
+	sev, ok := severityCodes[strings.ToUpper(s[:1])]
+	if !ok {
+		return SeverityUnknown, fmt.Errorf("Invalid severity: %v", s)
+	}
+	return sev, nil
+}
+
+// Entry is a single entry from an MQ error log
+type Entry struct {
+	Time         time.Time
+	ProcessID    int
+	ThreadID     int
+	User         string
+	Program      string
+	Host         string
+	Installation string
+	VRMF         string
+	QueueManager string
+	// MessageID is the AMQ message identifier, for example "AMQ5051I"
+	MessageID   string
+	Severity    Severity
+	Message     string
+	Explanation string
+	Action      string
+	SourceFile  string
+	SourceLine  int
+}
+
+// Layouts used by different MQ versions for the time at the start of each
+// entry.  Newer versions of MQ also include a "Time" attribute in ISO 8601
+// format, which is used in preference.
+var timeLayouts = []string{
+	"01/02/2006 03:04:05 PM",
+	"01/02/2006 15:04:05",
+	"01/02/06 15:04:05",
+}
+
+var separatorRegexp = regexp.MustCompile(`^-----(?: (\S+) : (\d+) )?-*$`)
+var messageRegexp = regexp.MustCompile(`^(AMQ\d{4,5})([IWEST]?): ?(.*)$`)
+
+// isSeparator returns true if the line is the separator which marks the end
+// of an entry
+func isSeparator(line string) bool {
+	return separatorRegexp.MatchString(strings.TrimSpace(line))
+}
+
+// parseAttributes parses all the "Name(value)" attributes in a line.  Values
+// may contain balanced parentheses.
+func parseAttributes(line string, attrs map[string]string) {
+	for i := 0; i < len(line); {
+		open := strings.Index(line[i:], "(")
+		if open < 0 {
+			return
+		}
+		open += i
+		start := strings.LastIndexAny(line[:open], " \t")
+		key := line[start+1 : open]
+		depth := 0
+		end := -1
+		for j := open; j < len(line); j++ {
+			if line[j] == '(' {
+				depth++
+			} else if line[j] == ')' {
+				depth--
+				if depth == 0 {
+					end = j
+					break
+				}
+			}
+		}
+		if end < 0 {
+			// Unbalanced parentheses, so take the rest of the line
+			end = len(line)
+		}
+		if key != "" {
+			attrs[key] = line[open+1 : end]
+		}
+		i = end + 1
+	}
+}
+
+// ParseEntry parses the lines of a single error log entry, including the
+// separator line at the end
+func ParseEntry(lines []string) (*Entry, error) {
+	e := &Entry{}
+	i := 0
+	// Skip any leading blank lines
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	if i >= len(lines) {
+		return nil, fmt.Errorf("Empty error log entry")
+	}
+	first := lines[i]
+	dash := strings.Index(first, " - ")
+	if dash < 0 {
+		return nil, fmt.Errorf("Unable to find time in error log entry: %v", first)
+	}
+	timeText := strings.TrimSpace(first[:dash])
+	// The header is everything up to the first blank line
+	attrs := make(map[string]string)
+	parseAttributes(first[dash+3:], attrs)
+	for i++; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		if messageRegexp.MatchString(strings.TrimSpace(lines[i])) {
+			// Some older versions don't have a blank line before the message
+			break
+		}
+		parseAttributes(lines[i], attrs)
+	}
+	var err error
+	if t, ok := attrs["Time"]; ok {
+		e.Time, err = time.Parse(time.RFC3339Nano, t)
+	} else {
+		for _, layout := range timeLayouts {
+			e.Time, err = time.ParseInLocation(layout, timeText, time.Local)
+			if err == nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse time in error log entry: %v", timeText)
+	}
+	if p, ok := attrs["Process"]; ok {
+		ids := strings.SplitN(p, ".", 2)
+		e.ProcessID, _ = strconv.Atoi(ids[0])
+		if len(ids) > 1 {
+			e.ThreadID, _ = strconv.Atoi(ids[1])
+		}
+	}
+	e.User = attrs["User"]
+	e.Program = attrs["Program"]
+	e.Host = attrs["Host"]
+	e.Installation = attrs["Installation"]
+	e.VRMF = attrs["VRMF"]
+	e.QueueManager = attrs["QMgr"]
+
+	// The message follows the header, and continues up to the next blank line
+	var section *string
+	var text []string
+	flush := func() {
+		if section != nil {
+			*section = strings.TrimSpace(strings.Join(text, " "))
+		}
+		text = nil
+	}
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case isSeparator(line):
+			flush()
+			section = nil
+			m := separatorRegexp.FindStringSubmatch(line)
+			e.SourceFile = m[1]
+			e.SourceLine, _ = strconv.Atoi(m[2])
+		case e.MessageID == "" && messageRegexp.MatchString(line):
+			m := messageRegexp.FindStringSubmatch(line)
+			e.MessageID = m[1] + m[2]
+			if m[2] != "" {
+				e.Severity = severityCodes[m[2]]
+			}
+			section = &e.Message
+			text = []string{m[3]}
+		case line == "EXPLANATION:":
+			flush()
+			section = &e.Explanation
+		case line == "ACTION:":
+			flush()
+			section = &e.Action
+		case line == "":
+			if section == &e.Message {
+				flush()
+				section = nil
+			}
+		default:
+			text = append(text, line)
+		}
+	}
+	flush()
+	if e.MessageID == "" {
+		return nil, fmt.Errorf("Unable to find message ID in error log entry: %v", first)
+	}
+	return e, nil
+}
+
+// Parser parses error log entries one line at a time, which is useful when
+// reading from a file which is still being written to
+type Parser struct {
+	lines []string
+}
+
+// ParseLine adds a line to the entry currently being parsed.  If the line
+// completes an entry, then the entry is returned.  If the completed entry
+// can't be parsed, then the error is returned, along with the raw lines.
+func (p *Parser) ParseLine(line string) (*Entry, []string, error) {
+	p.lines = append(p.lines, line)
+	if !isSeparator(line) {
+		return nil, nil, nil
+	}
+	lines := p.lines
+	p.lines = nil
+	e, err := ParseEntry(lines)
+	if err != nil {
+		return nil, lines, err
+	}
+	return e, lines, nil
+}
+
+// Parse parses all the complete entries from an error log file.  Entries
+// which can't be parsed are skipped.
+func Parse(r io.Reader) ([]*Entry, error) {
+	entries := make([]*Entry, 0)
+	p := &Parser{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		e, _, err := p.ParseLine(scanner.Text())
+		if err == nil && e != nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
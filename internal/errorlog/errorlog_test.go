@@ -0,0 +1,240 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package errorlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Sample log from MQ V7.0, which doesn't include the severity in the message
+// ID, or the installation name
+const logV70 string = `11/06/09 10:21:45 - Process(3152.1) User(mqm) Program(amqzxma0)
+                    Host(host1)
+AMQ8003: WebSphere MQ queue manager 'QM1' started.
+
+EXPLANATION:
+WebSphere MQ queue manager 'QM1' started.
+ACTION:
+None.
+-------------------------------------------------------------------------------
+`
+
+// Sample log from MQ V7.5, with a 12-hour clock
+const logV75 string = `02/13/2014 09:08:36 PM - Process(5014.4) User(mqm) Program(amqrmppa)
+                    Host(host1) Installation(Installation1)
+                    VRMF(7.5.0.2) QMgr(QM1)
+
+AMQ9209E: Connection to host 'client1 (10.0.0.1)' for channel
+'SYSTEM.DEF.SVRCONN' closed.
+
+EXPLANATION:
+An error occurred receiving data from 'client1 (10.0.0.1)' over TCP/IP. The
+connection to the remote host has unexpectedly terminated.
+ACTION:
+Tell the systems administrator.
+----- amqccita.c : 4063 -------------------------------------------------------
+`
+
+// Sample log from MQ V9.0, with two entries, and an ISO 8601 time
+const logV90 string = `11/06/2017 10:21:45 AM - Process(1234.1) User(mqm) Program(amqzxma0)
+                    Host(host1) Installation(Installation1)
+                    VRMF(9.0.4.0) QMgr(QM1)
+                    Time(2017-11-06T10:21:45.123Z)
+                    CommentInsert1(LOGGER-IO)
+
+AMQ5051I: The queue manager task 'LOGGER-IO' has started.
+
+EXPLANATION:
+The critical utility task manager has started the LOGGER-IO task. This task has
+now started 1 times.
+ACTION:
+None.
+----- amqzmuc0.c : 1234 -------------------------------------------------------
+11/06/2017 10:21:46 AM - Process(1234.7) User(mqm) Program(amqzxma0)
+                    Host(host1) Installation(Installation1)
+                    VRMF(9.0.4.0) QMgr(QM1)
+                    Time(2017-11-06T10:21:46.456Z)
+                    CommentInsert1(/var/mqm/log/QM1/active (S0000000.LOG))
+
+AMQ6119S: An internal IBM MQ error has occurred (Failed to open log file)
+
+EXPLANATION:
+MQ detected an unexpected error when calling the operating system.
+ACTION:
+Use the standard facilities supplied with your system to record the problem
+identifier and to save any generated output files.
+----- amqxfdcp.c : 987 --------------------------------------------------------
+`
+
+func TestParseV70(t *testing.T) {
+	entries, err := Parse(strings.NewReader(logV70))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %v", len(entries))
+	}
+	e := entries[0]
+	if e.MessageID != "AMQ8003" {
+		t.Errorf("Expected message ID AMQ8003, got %v", e.MessageID)
+	}
+	if e.Severity != SeverityUnknown {
+		t.Errorf("Expected unknown severity, got %v", e.Severity)
+	}
+	if e.Installation != "" {
+		t.Errorf("Expected no installation, got %v", e.Installation)
+	}
+	if e.Message != "WebSphere MQ queue manager 'QM1' started." {
+		t.Errorf("Unexpected message: %v", e.Message)
+	}
+	expected := time.Date(2009, 11, 6, 10, 21, 45, 0, time.Local)
+	if !e.Time.Equal(expected) {
+		t.Errorf("Expected time %v, got %v", expected, e.Time)
+	}
+}
+
+func TestParseV75(t *testing.T) {
+	entries, err := Parse(strings.NewReader(logV75))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %v", len(entries))
+	}
+	e := entries[0]
+	var fieldTests = []struct {
+		name     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"ProcessID", e.ProcessID, 5014},
+		{"ThreadID", e.ThreadID, 4},
+		{"User", e.User, "mqm"},
+		{"Program", e.Program, "amqrmppa"},
+		{"Host", e.Host, "host1"},
+		{"Installation", e.Installation, "Installation1"},
+		{"VRMF", e.VRMF, "7.5.0.2"},
+		{"QueueManager", e.QueueManager, "QM1"},
+		{"MessageID", e.MessageID, "AMQ9209E"},
+		{"Severity", e.Severity, SeverityError},
+		{"Message", e.Message, "Connection to host 'client1 (10.0.0.1)' for channel 'SYSTEM.DEF.SVRCONN' closed."},
+		{"Action", e.Action, "Tell the systems administrator."},
+		{"SourceFile", e.SourceFile, "amqccita.c"},
+		{"SourceLine", e.SourceLine, 4063},
+	}
+	for _, table := range fieldTests {
+		if table.value != table.expected {
+			t.Errorf("Expected %v=%v, got %v", table.name, table.expected, table.value)
+		}
+	}
+	expected := time.Date(2014, 2, 13, 21, 8, 36, 0, time.Local)
+	if !e.Time.Equal(expected) {
+		t.Errorf("Expected time %v, got %v", expected, e.Time)
+	}
+}
+
+func TestParseV90(t *testing.T) {
+	entries, err := Parse(strings.NewReader(logV90))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %v", len(entries))
+	}
+	if entries[0].MessageID != "AMQ5051I" || entries[0].Severity != SeverityInformation {
+		t.Errorf("Expected AMQ5051I with severity I, got %v with severity %v", entries[0].MessageID, entries[0].Severity)
+	}
+	expected := "The critical utility task manager has started the LOGGER-IO task. This task has now started 1 times."
+	if entries[0].Explanation != expected {
+		t.Errorf("Expected explanation %v, got %v", expected, entries[0].Explanation)
+	}
+	e := entries[1]
+	if e.MessageID != "AMQ6119S" || e.Severity != SeveritySevere {
+		t.Errorf("Expected AMQ6119S with severity S, got %v with severity %v", e.MessageID, e.Severity)
+	}
+	expectedTime := time.Date(2017, 11, 6, 10, 21, 46, 456000000, time.UTC)
+	if !e.Time.Equal(expectedTime) {
+		t.Errorf("Expected time %v, got %v", expectedTime, e.Time)
+	}
+	if e.ThreadID != 7 {
+		t.Errorf("Expected thread 7, got %v", e.ThreadID)
+	}
+	if e.SourceFile != "amqxfdcp.c" || e.SourceLine != 987 {
+		t.Errorf("Expected source amqxfdcp.c:987, got %v:%v", e.SourceFile, e.SourceLine)
+	}
+}
+
+// TestParseLine checks that entries are returned as soon as they're complete
+func TestParseLine(t *testing.T) {
+	p := &Parser{}
+	count := 0
+	for _, line := range strings.Split(logV90, "\n") {
+		e, _, err := p.ParseLine(line)
+		if err != nil {
+			t.Error(err)
+		}
+		if e != nil {
+			count++
+			if !isSeparator(line) {
+				t.Errorf("Expected entry to be completed by separator, got %v", line)
+			}
+		}
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 entries, got %v", count)
+	}
+}
+
+func TestParseLineInvalid(t *testing.T) {
+	p := &Parser{}
+	p.ParseLine("not an error log")
+	e, lines, err := p.ParseLine("-----")
+	if err == nil || e != nil {
+		t.Errorf("Expected error for invalid entry, got %v", e)
+	}
+	if len(lines) != 2 {
+		t.Errorf("Expected raw lines to be returned, got %v", lines)
+	}
+}
+
+var severityTests = []struct {
+	in  string
+	out Severity
+}{
+	{"I", SeverityInformation},
+	{"w", SeverityWarning},
+	{"error", SeverityError},
+	{"S", SeveritySevere},
+	{"T", SeverityTermination},
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, table := range severityTests {
+		s, err := ParseSeverity(table.in)
+		if err != nil {
+			t.Error(err)
+		}
+		if s != table.out {
+			t.Errorf("ParseSeverity(%v) - expected %v, got %v", table.in, table.out, s)
+		}
+	}
+	_, err := ParseSeverity("X")
+	if err == nil {
+		t.Error("Expected error for invalid severity")
+	}
+}
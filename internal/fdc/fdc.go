@@ -0,0 +1,163 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fdc contains code to parse MQ First Failure Data Capture (FDC) files
+package fdc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Report is the header of a single First Failure Symptom Report.  An FDC
+// file can contain several reports, if the same process fails more than once.
+type Report struct {
+	DateTime         string
+	HostName         string
+	ProbeID          string
+	Component        string
+	Program          string
+	ProcessID        int
+	ThreadID         int
+	QueueManager     string
+	MajorErrorcode   string
+	MinorErrorcode   string
+	ProbeType        string
+	ProbeDescription string
+	Comments         []string
+	// Fields holds all the fields in the header, keyed by name
+	Fields map[string]string
+}
+
+const reportTitle string = "First Failure Symptom Report"
+
+// CountFile is the file used to share the number of FDC reports detected by
+// runmqserver since it started
+const CountFile string = "/run/runmqserver/fdccount"
+
+// Summary returns a one-line summary of the report
+func (r *Report) Summary() string {
+	s := fmt.Sprintf("FDC %v from %v (PID %v) in component %v: %v", r.ProbeID, r.Program, r.ProcessID, r.Component, r.MajorErrorcode)
+	if r.ProbeDescription != "" {
+		s += " - " + r.ProbeDescription
+	}
+	if len(r.Comments) > 0 {
+		s += " - " + strings.Join(r.Comments, "; ")
+	}
+	return s
+}
+
+// parseHeaderLine parses a line such as "| Probe Id          :- XC130003   |"
+// into a name and value
+func parseHeaderLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "|") {
+		return "", "", false
+	}
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	parts := strings.SplitN(line, ":-", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+func newReport(fields map[string]string, comments []string) *Report {
+	r := &Report{
+		DateTime:         fields["Date/Time"],
+		HostName:         fields["Host Name"],
+		ProbeID:          fields["Probe Id"],
+		Component:        fields["Component"],
+		Program:          fields["Program Name"],
+		QueueManager:     fields["QueueManager"],
+		MajorErrorcode:   fields["Major Errorcode"],
+		MinorErrorcode:   fields["Minor Errorcode"],
+		ProbeType:        fields["Probe Type"],
+		ProbeDescription: fields["Probe Description"],
+		Comments:         comments,
+		Fields:           fields,
+	}
+	r.ProcessID, _ = strconv.Atoi(fields["Process"])
+	r.ThreadID, _ = strconv.Atoi(fields["Thread"])
+	return r
+}
+
+// Parse parses the header of each report in an FDC file.  Only complete
+// headers are returned, so a file which is still being written can be parsed
+// again later to find any further reports.
+func Parse(r io.Reader) ([]*Report, error) {
+	reports := make([]*Report, 0)
+	var fields map[string]string
+	var comments []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.Contains(line, reportTitle):
+			fields = make(map[string]string)
+			comments = nil
+		case fields == nil:
+			// Not in a report header
+		case strings.HasPrefix(strings.TrimSpace(line), "+-"):
+			// The end of the header box
+			if len(fields) > 0 {
+				reports = append(reports, newReport(fields, comments))
+			}
+			fields = nil
+		default:
+			name, value, ok := parseHeaderLine(line)
+			if !ok {
+				continue
+			}
+			if strings.HasPrefix(name, "Comment") {
+				if value != "" {
+					comments = append(comments, value)
+				}
+			}
+			fields[name] = value
+		}
+	}
+	return reports, scanner.Err()
+}
+
+// WriteCount writes the number of FDC reports detected to a file, so that
+// it can be read by other processes, such as the health check
+func WriteCount(path string, count int) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(strconv.Itoa(count)), 0644)
+}
+
+// ReadCount reads the number of FDC reports detected from a file written by
+// WriteCount.  If the file doesn't exist, then the count is zero.
+func ReadCount(path string) (int, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(buf)))
+}
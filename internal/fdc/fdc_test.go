@@ -0,0 +1,103 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fdc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const header string = `+-----------------------------------------------------------------------------+
+|                                                                             |
+| IBM MQ First Failure Symptom Report                                         |
+| ===================================                                         |
+|                                                                             |
+| Date/Time         :- Mon November 06 2017 10:21:45 UTC                      |
+| Host Name         :- host1                                                  |
+| LVLS              :- 9.0.4.0                                                |
+| Probe Id          :- XC130003                                               |
+| Application Name  :- MQM                                                    |
+| Component         :- xehExceptionHandler                                    |
+| Program Name      :- amqzxma0                                               |
+| Process           :- 1234                                                   |
+| Thread            :- 3                                                      |
+| QueueManager      :- QM1                                                    |
+| Major Errorcode   :- STOP_ALL_SERVICES                                      |
+| Minor Errorcode   :- OK                                                     |
+| Probe Type        :- HALT6109                                               |
+| Probe Description :- AMQ6109: An internal IBM MQ error has occurred.        |
+| FDCSequenceNumber :- 0                                                      |
+| Comment1          :- SIGSEGV: address not mapped(0x0)                       |
+| Comment2          :- Signal sent by pid 0                                   |
+|                                                                             |
++-----------------------------------------------------------------------------+
+
+MQM Function Stack
+amqzxma0
+xcsFFST
+
+`
+
+func TestParse(t *testing.T) {
+	reports, err := Parse(strings.NewReader(header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 report, got %v", len(reports))
+	}
+	r := reports[0]
+	var fieldTests = []struct {
+		name     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"ProbeID", r.ProbeID, "XC130003"},
+		{"Component", r.Component, "xehExceptionHandler"},
+		{"Program", r.Program, "amqzxma0"},
+		{"ProcessID", r.ProcessID, 1234},
+		{"ThreadID", r.ThreadID, 3},
+		{"QueueManager", r.QueueManager, "QM1"},
+		{"MajorErrorcode", r.MajorErrorcode, "STOP_ALL_SERVICES"},
+		{"ProbeDescription", r.ProbeDescription, "AMQ6109: An internal IBM MQ error has occurred."},
+	}
+	for _, table := range fieldTests {
+		if table.value != table.expected {
+			t.Errorf("Expected %v=%v, got %v", table.name, table.expected, table.value)
+		}
+	}
+	comments := []string{"SIGSEGV: address not mapped(0x0)", "Signal sent by pid 0"}
+	if !reflect.DeepEqual(r.Comments, comments) {
+		t.Errorf("Expected comments %v, got %v", comments, r.Comments)
+	}
+	if !strings.Contains(r.Summary(), "XC130003") {
+		t.Errorf("Expected summary to contain probe ID, got %v", r.Summary())
+	}
+}
+
+// TestParseMultiple checks that all reports in a file are found, and that an
+// incomplete report at the end of the file is ignored
+func TestParseMultiple(t *testing.T) {
+	incomplete := strings.Join(strings.Split(header, "\n")[:10], "\n")
+	reports, err := Parse(strings.NewReader(header + header + incomplete))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 {
+		t.Errorf("Expected 2 reports, got %v", len(reports))
+	}
+}
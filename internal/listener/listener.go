@@ -0,0 +1,190 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package listener contains code to configure the queue manager's TCP
+// listeners, from the MQ_LISTENER_PORTS environment variable
+package listener
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultPort is the port used when MQ_LISTENER_PORTS isn't set
+const DefaultPort = 1414
+
+var hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+
+// Listener is a TCP listener, with an optional address to bind to
+type Listener struct {
+	Port int
+	// Address is the IP address or host name to listen on.  If it's empty,
+	// then the listener uses all the available addresses.
+	Address string
+}
+
+// Name returns the name of the listener object
+func (l Listener) Name() string {
+	return fmt.Sprintf("LISTENER.TCP.%v", l.Port)
+}
+
+// String returns the listener in the same form as in MQ_LISTENER_PORTS
+func (l Listener) String() string {
+	if l.Address == "" {
+		return strconv.Itoa(l.Port)
+	}
+	return net.JoinHostPort(l.Address, strconv.Itoa(l.Port))
+}
+
+// ProbeAddress returns the address to connect to, to check that the listener
+// is available.  Listeners on all addresses are checked using the loopback
+// address.
+func (l Listener) ProbeAddress() string {
+	host := l.Address
+	switch {
+	case host == "" || host == "0.0.0.0":
+		host = "127.0.0.1"
+	case host == "::":
+		host = "::1"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(l.Port))
+}
+
+// parseListener parses a single entry, which is a port, optionally preceded
+// by an address, such as "1414", "10.0.0.1:1414" or "[::1]:1414"
+func parseListener(s string) (Listener, error) {
+	l := Listener{}
+	port := s
+	if strings.Contains(s, ":") {
+		var err error
+		l.Address, port, err = net.SplitHostPort(s)
+		if err != nil {
+			return l, fmt.Errorf("Invalid listener %v: IPv6 addresses must be in square brackets, such as [::1]:1414", s)
+		}
+		if l.Address == "" {
+			return l, fmt.Errorf("Invalid listener %v: missing address", s)
+		}
+		if net.ParseIP(l.Address) == nil && (strings.Contains(l.Address, ":") || !hostnamePattern.MatchString(l.Address)) {
+			return l, fmt.Errorf("Invalid listener %v: invalid address %v", s, l.Address)
+		}
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 1 || p > 65535 {
+		return l, fmt.Errorf("Invalid listener %v: the port must be between 1 and 65535", s)
+	}
+	l.Port = p
+	return l, nil
+}
+
+// Parse parses a comma-separated list of listeners.  Each port can only be
+// used once, as it is used to name the listener object.
+func Parse(s string) ([]Listener, error) {
+	listeners := make([]Listener, 0)
+	ports := make(map[int]bool)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		l, err := parseListener(entry)
+		if err != nil {
+			return nil, err
+		}
+		if ports[l.Port] {
+			return nil, fmt.Errorf("Invalid listener %v: port %v is used more than once", entry, l.Port)
+		}
+		ports[l.Port] = true
+		listeners = append(listeners, l)
+	}
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("No listeners specified")
+	}
+	return listeners, nil
+}
+
+// FromEnv returns the listeners set in MQ_LISTENER_PORTS, or nil if the
+// variable isn't set
+func FromEnv() ([]Listener, error) {
+	s := os.Getenv("MQ_LISTENER_PORTS")
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	listeners, err := Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid value for MQ_LISTENER_PORTS: %v", err)
+	}
+	return listeners, nil
+}
+
+// Configured returns the listeners set in MQ_LISTENER_PORTS, or the default
+// listener if the variable isn't set
+func Configured() ([]Listener, error) {
+	listeners, err := FromEnv()
+	if err != nil || listeners != nil {
+		return listeners, err
+	}
+	return []Listener{{Port: DefaultPort}}, nil
+}
+
+// NamePattern is the generic name which matches all the listener objects
+// defined from MQ_LISTENER_PORTS.  Other listeners might also match.
+const NamePattern string = "LISTENER.TCP.*"
+
+// Description is the description of the listener objects defined from
+// MQ_LISTENER_PORTS, which marks them as managed by runmqserver, so that any
+// listeners defined in other ways aren't changed
+const Description string = "Defined from MQ_LISTENER_PORTS"
+
+// defaultListener is the listener created by crtmqm
+const defaultListener string = "SYSTEM.LISTENER.TCP.1"
+
+// MQSC returns the commands to define and start the listeners.  The
+// listeners are controlled by the queue manager, so are started again
+// whenever the queue manager starts.  The default listener created by crtmqm
+// is stopped, as the configured listeners replace it.  If no listeners are
+// configured, then the default listener is restored instead.  Any of the
+// existing managed listener objects which are no longer configured are
+// stopped, and won't be started again.
+func MQSC(listeners []Listener, existing []string) string {
+	var buf bytes.Buffer
+	configured := make(map[string]bool)
+	for _, l := range listeners {
+		configured[l.Name()] = true
+	}
+	for _, name := range existing {
+		if !configured[name] {
+			fmt.Fprintf(&buf, "ALTER LISTENER(%v) TRPTYPE(TCP) CONTROL(MANUAL)\n", name)
+			fmt.Fprintf(&buf, "STOP LISTENER(%v) IGNSTATE(YES)\n", name)
+		}
+	}
+	if len(listeners) == 0 {
+		fmt.Fprintf(&buf, "ALTER LISTENER(%v) TRPTYPE(TCP) PORT(%v) CONTROL(QMGR)\n", defaultListener, DefaultPort)
+		fmt.Fprintf(&buf, "START LISTENER(%v) IGNSTATE(YES)\n", defaultListener)
+		return buf.String()
+	}
+	fmt.Fprintf(&buf, "ALTER LISTENER(%v) TRPTYPE(TCP) CONTROL(MANUAL)\n", defaultListener)
+	fmt.Fprintf(&buf, "STOP LISTENER(%v) IGNSTATE(YES)\n", defaultListener)
+	for _, l := range listeners {
+		fmt.Fprintf(&buf, "DEFINE LISTENER(%v) TRPTYPE(TCP) PORT(%v) IPADDR('%v') CONTROL(QMGR) DESCR('%v') REPLACE\n", l.Name(), l.Port, l.Address, Description)
+		fmt.Fprintf(&buf, "START LISTENER(%v) IGNSTATE(YES)\n", l.Name())
+	}
+	return buf.String()
+}
@@ -0,0 +1,135 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package listener
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var parseTests = []struct {
+	in        string
+	listeners []Listener
+	err       bool
+}{
+	{"1414", []Listener{{Port: 1414}}, false},
+	{"1414, 1415", []Listener{{Port: 1414}, {Port: 1415}}, false},
+	{"10.0.0.1:1414,[::1]:1415,[::]:1416", []Listener{{1414, "10.0.0.1"}, {1415, "::1"}, {1416, "::"}}, false},
+	{"mq.example.com:1414", []Listener{{1414, "mq.example.com"}}, false},
+	{"", nil, true},
+	{"0", nil, true},
+	{"65536", nil, true},
+	{"abc", nil, true},
+	{"::1:1414", nil, true},
+	{":1414", nil, true},
+	{"bad_host:1414", nil, true},
+	{"1414,10.0.0.1:1414", nil, true},
+}
+
+func TestParse(t *testing.T) {
+	for _, table := range parseTests {
+		listeners, err := Parse(table.in)
+		if table.err {
+			if err == nil {
+				t.Errorf("Parse(%v) - expected error, got %v", table.in, listeners)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%v) - unexpected error: %v", table.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(listeners, table.listeners) {
+			t.Errorf("Parse(%v) - expected %v, got %v", table.in, table.listeners, listeners)
+		}
+	}
+}
+
+var probeAddressTests = []struct {
+	listener Listener
+	address  string
+}{
+	{Listener{Port: 1414}, "127.0.0.1:1414"},
+	{Listener{1414, "0.0.0.0"}, "127.0.0.1:1414"},
+	{Listener{1414, "::"}, "[::1]:1414"},
+	{Listener{1414, "::1"}, "[::1]:1414"},
+	{Listener{1414, "10.0.0.1"}, "10.0.0.1:1414"},
+}
+
+func TestProbeAddress(t *testing.T) {
+	for _, table := range probeAddressTests {
+		a := table.listener.ProbeAddress()
+		if a != table.address {
+			t.Errorf("ProbeAddress(%v) - expected %v, got %v", table.listener, table.address, a)
+		}
+	}
+}
+
+func TestConfigured(t *testing.T) {
+	defer os.Unsetenv("MQ_LISTENER_PORTS")
+	os.Unsetenv("MQ_LISTENER_PORTS")
+	listeners, err := Configured()
+	if err != nil || !reflect.DeepEqual(listeners, []Listener{{Port: DefaultPort}}) {
+		t.Errorf("Configured() - expected the default listener, got %v, %v", listeners, err)
+	}
+	os.Setenv("MQ_LISTENER_PORTS", "1415,[::1]:1416")
+	listeners, err = Configured()
+	if err != nil || len(listeners) != 2 {
+		t.Errorf("Configured() - expected 2 listeners, got %v, %v", listeners, err)
+	}
+	os.Setenv("MQ_LISTENER_PORTS", "x")
+	_, err = Configured()
+	if err == nil {
+		t.Error("Configured() - expected error for invalid MQ_LISTENER_PORTS")
+	}
+}
+
+func TestMQSC(t *testing.T) {
+	expected := "ALTER LISTENER(SYSTEM.LISTENER.TCP.1) TRPTYPE(TCP) CONTROL(MANUAL)\n" +
+		"STOP LISTENER(SYSTEM.LISTENER.TCP.1) IGNSTATE(YES)\n" +
+		"DEFINE LISTENER(LISTENER.TCP.1414) TRPTYPE(TCP) PORT(1414) IPADDR('') CONTROL(QMGR) DESCR('Defined from MQ_LISTENER_PORTS') REPLACE\n" +
+		"START LISTENER(LISTENER.TCP.1414) IGNSTATE(YES)\n" +
+		"DEFINE LISTENER(LISTENER.TCP.1415) TRPTYPE(TCP) PORT(1415) IPADDR('::1') CONTROL(QMGR) DESCR('Defined from MQ_LISTENER_PORTS') REPLACE\n" +
+		"START LISTENER(LISTENER.TCP.1415) IGNSTATE(YES)\n"
+	s := MQSC([]Listener{{Port: 1414}, {1415, "::1"}}, []string{"LISTENER.TCP.1414"})
+	if s != expected {
+		t.Errorf("MQSC() - expected %q, got %q", expected, s)
+	}
+}
+
+func TestMQSCUnset(t *testing.T) {
+	// MQ_LISTENER_PORTS was set to 1415,1416, and has now been removed
+	expected := "ALTER LISTENER(LISTENER.TCP.1415) TRPTYPE(TCP) CONTROL(MANUAL)\n" +
+		"STOP LISTENER(LISTENER.TCP.1415) IGNSTATE(YES)\n" +
+		"ALTER LISTENER(LISTENER.TCP.1416) TRPTYPE(TCP) CONTROL(MANUAL)\n" +
+		"STOP LISTENER(LISTENER.TCP.1416) IGNSTATE(YES)\n" +
+		"ALTER LISTENER(SYSTEM.LISTENER.TCP.1) TRPTYPE(TCP) PORT(1414) CONTROL(QMGR)\n" +
+		"START LISTENER(SYSTEM.LISTENER.TCP.1) IGNSTATE(YES)\n"
+	s := MQSC(nil, []string{"LISTENER.TCP.1415", "LISTENER.TCP.1416"})
+	if s != expected {
+		t.Errorf("MQSC() - expected %q, got %q", expected, s)
+	}
+}
+
+func TestMQSCChanged(t *testing.T) {
+	// Port 1415 has been removed from MQ_LISTENER_PORTS
+	s := MQSC([]Listener{{Port: 1416}}, []string{"LISTENER.TCP.1415", "LISTENER.TCP.1416"})
+	if !strings.Contains(s, "STOP LISTENER(LISTENER.TCP.1415)") || strings.Contains(s, "STOP LISTENER(LISTENER.TCP.1416)") {
+		t.Errorf("MQSC() - expected only LISTENER.TCP.1415 to be stopped, got %q", s)
+	}
+}
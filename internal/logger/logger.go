@@ -0,0 +1,172 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logger provides utility functions for logging purposes
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// FormatBasic logs each message as free text, prefixed with a timestamp
+	FormatBasic string = "basic"
+	// FormatJSON logs each message as a single-line JSON object
+	FormatJSON string = "json"
+)
+
+const (
+	levelDebug string = "DEBUG"
+	levelInfo  string = "INFO"
+	levelError string = "ERROR"
+)
+
+// Logger writes log messages to a writer, in either basic or JSON format.
+// It is safe to use a Logger from multiple goroutines.
+type Logger struct {
+	mutex        sync.Mutex
+	writer       io.Writer
+	debug        bool
+	json         bool
+	processName  string
+	pid          int
+	queueManager string
+	phase        string
+}
+
+// NewLogger creates a new Logger, which writes to the specified writer, in
+// the specified format.
+func NewLogger(writer io.Writer, debug bool, format string, processName string) (*Logger, error) {
+	var j bool
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		j = true
+	case FormatBasic, "":
+		j = false
+	default:
+		return nil, fmt.Errorf("Unsupported log format: %v", format)
+	}
+	return &Logger{
+		writer:      writer,
+		debug:       debug,
+		json:        j,
+		processName: processName,
+		pid:         os.Getpid(),
+	}, nil
+}
+
+// NewLoggerFromEnv creates a new Logger, which writes to stdout.  The format
+// is taken from the LOG_FORMAT environment variable, and debug messages are
+// enabled using the DEBUG environment variable.
+func NewLoggerFromEnv() (*Logger, error) {
+	debug := false
+	debugEnv, ok := os.LookupEnv("DEBUG")
+	if ok && (debugEnv == "true" || debugEnv == "1") {
+		debug = true
+	}
+	return NewLogger(os.Stdout, debug, os.Getenv("LOG_FORMAT"), filepath.Base(os.Args[0]))
+}
+
+// SetQueueManager sets the name of the queue manager, which is included in
+// all subsequent JSON log messages
+func (l *Logger) SetQueueManager(name string) {
+	l.mutex.Lock()
+	l.queueManager = name
+	l.mutex.Unlock()
+}
+
+// SetPhase sets the current phase of processing (for example, "crtmqm"),
+// which is included in all subsequent JSON log messages
+func (l *Logger) SetPhase(phase string) {
+	l.mutex.Lock()
+	l.phase = phase
+	l.mutex.Unlock()
+}
+
+func (l *Logger) log(level string, msg string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	t := time.Now()
+	if l.json {
+		entry := map[string]interface{}{
+			"timestamp": t.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+			"level":     level,
+			"program":   l.processName,
+			"pid":       l.pid,
+			"message":   msg,
+		}
+		if l.phase != "" {
+			entry["phase"] = l.phase
+		}
+		if l.queueManager != "" {
+			entry["qmgr"] = l.queueManager
+		}
+		// Marshalling a map of strings and integers can't fail
+		buf, _ := json.Marshal(entry)
+		fmt.Fprintln(l.writer, string(buf))
+		return
+	}
+	if level == levelDebug {
+		msg = "DEBUG: " + msg
+	}
+	fmt.Fprintf(l.writer, "%v %v\n", t.Format("2006/01/02 15:04:05"), strings.TrimSuffix(msg, "\n"))
+}
+
+// Debug logs a message at debug level, if debug is enabled
+func (l *Logger) Debug(args ...interface{}) {
+	if l.debug {
+		l.log(levelDebug, fmt.Sprint(args...))
+	}
+}
+
+// Debugf logs a formatted message at debug level, if debug is enabled
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if l.debug {
+		l.log(levelDebug, fmt.Sprintf(format, args...))
+	}
+}
+
+// Print logs a message at info level
+func (l *Logger) Print(args ...interface{}) {
+	l.log(levelInfo, fmt.Sprint(args...))
+}
+
+// Println logs a message at info level
+func (l *Logger) Println(args ...interface{}) {
+	l.log(levelInfo, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
+
+// Printf logs a formatted message at info level
+func (l *Logger) Printf(format string, args ...interface{}) {
+	l.log(levelInfo, fmt.Sprintf(format, args...))
+}
+
+// Error logs a message at error level
+func (l *Logger) Error(args ...interface{}) {
+	l.log(levelError, fmt.Sprint(args...))
+}
+
+// Errorf logs a formatted message at error level
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.log(levelError, fmt.Sprintf(format, args...))
+}
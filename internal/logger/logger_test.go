@@ -0,0 +1,96 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := NewLogger(buf, true, "json", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetQueueManager("qm1")
+	l.SetPhase("crtmqm")
+	l.Errorf("Error %v", 2)
+	var e map[string]interface{}
+	err = json.Unmarshal(buf.Bytes(), &e)
+	if err != nil {
+		t.Fatalf("Expected valid JSON, got %v: %v", buf.String(), err)
+	}
+	var fieldTests = []struct {
+		field string
+		value interface{}
+	}{
+		{"level", "ERROR"},
+		{"phase", "crtmqm"},
+		{"qmgr", "qm1"},
+		{"program", t.Name()},
+		{"message", "Error 2"},
+	}
+	for _, table := range fieldTests {
+		if e[table.field] != table.value {
+			t.Errorf("Expected %v=%v, got %v", table.field, table.value, e[table.field])
+		}
+	}
+	if _, ok := e["timestamp"]; !ok {
+		t.Errorf("Expected timestamp field, got %v", buf.String())
+	}
+}
+
+func TestBasicLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := NewLogger(buf, true, "basic", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Println("hello")
+	l.Debug("world")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %v", len(lines))
+	}
+	if !strings.HasSuffix(lines[0], " hello") {
+		t.Errorf("Expected line to end with \"hello\", got %v", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], " DEBUG: world") {
+		t.Errorf("Expected line to end with \"DEBUG: world\", got %v", lines[1])
+	}
+}
+
+func TestDebugDisabled(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := NewLogger(buf, false, "json", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Debugf("Debug %v", 1)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output, got %v", buf.String())
+	}
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := NewLogger(new(bytes.Buffer), false, "xml", t.Name())
+	if err == nil {
+		t.Error("Expected error for unsupported log format")
+	}
+}
@@ -0,0 +1,201 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migrate contains code to find versioned MQSC migrations, which are
+// applied to a queue manager exactly once, and to record which ones have been
+// applied
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filePattern matches the name of a migration file, such as
+// "V001__create_queues.mqsc"
+var filePattern = regexp.MustCompile(`^V(\d+)__(.+)\.mqsc$`)
+
+// Migration is a versioned MQSC file
+type Migration struct {
+	Version     int
+	Description string
+	Path        string
+	// Checksum is the SHA-256 hash of the file's contents
+	Checksum string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("V%03d (%v)", m.Version, filepath.Base(m.Path))
+}
+
+// Checksum returns the checksum of a migration file's contents
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Find returns the migrations in a directory, sorted by version.  Files
+// which don't have a ".mqsc" extension are ignored.  If the directory
+// doesn't exist, then there are no migrations.
+func Find(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Migration{}, nil
+		}
+		return nil, err
+	}
+	migrations := make([]Migration, 0)
+	versions := make(map[int]string)
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".mqsc") {
+			continue
+		}
+		m := filePattern.FindStringSubmatch(file.Name())
+		if m == nil {
+			return nil, fmt.Errorf("Invalid migration file name %v: expected a name such as V001__create_queues.mqsc", file.Name())
+		}
+		version, err := strconv.Atoi(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid migration version in %v: %v", file.Name(), err)
+		}
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("Migration files %v and %v have the same version", other, file.Name())
+		}
+		versions[version] = file.Name()
+		path := filepath.Join(dir, file.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{
+			Version:     version,
+			Description: strings.Replace(m[2], "_", " ", -1),
+			Path:        path,
+			Checksum:    Checksum(data),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Applied records a migration which has been applied to the queue manager
+type Applied struct {
+	Version     int       `json:"version"`
+	Description string    `json:"description"`
+	File        string    `json:"file"`
+	Checksum    string    `json:"checksum"`
+	AppliedAt   time.Time `json:"appliedAt"`
+}
+
+// State holds the migrations which have been applied, in the order they
+// were applied
+type State struct {
+	Applied []Applied `json:"applied"`
+}
+
+// LoadState reads the state file.  If the file doesn't exist, then no
+// migrations have been applied.
+func LoadState(path string) (*State, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{Applied: []Applied{}}, nil
+		}
+		return nil, err
+	}
+	s := &State{}
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid migration state file %v: %v", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the state file.  The file is replaced atomically, so that it
+// isn't left incomplete if the container is stopped while writing it.
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, append(data, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Record adds a migration to the state, as having been applied now
+func (s *State) Record(m Migration) {
+	s.Applied = append(s.Applied, Applied{
+		Version:     m.Version,
+		Description: m.Description,
+		File:        filepath.Base(m.Path),
+		Checksum:    m.Checksum,
+		AppliedAt:   time.Now().UTC(),
+	})
+}
+
+// Pending checks the migrations against the ones which have been applied,
+// and returns those which haven't been applied yet.  An error is returned if
+// an applied migration has been changed or removed, or if a new migration
+// has a lower version than one which has already been applied.
+func (s *State) Pending(migrations []Migration) ([]Migration, error) {
+	byVersion := make(map[int]Migration)
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+	applied := make(map[int]bool)
+	latest := 0
+	for _, a := range s.Applied {
+		m, ok := byVersion[a.Version]
+		if !ok {
+			return nil, fmt.Errorf("Migration V%03d (%v) has been applied, but the file no longer exists", a.Version, a.File)
+		}
+		if m.Checksum != a.Checksum {
+			return nil, fmt.Errorf("Migration %v has been changed since it was applied on %v.  Applied migrations must not be edited: create a new migration instead", m, a.AppliedAt.Format(time.RFC3339))
+		}
+		applied[a.Version] = true
+		if a.Version > latest {
+			latest = a.Version
+		}
+	}
+	pending := make([]Migration, 0)
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		if m.Version < latest {
+			return nil, fmt.Errorf("Migration %v has a lower version than V%03d, which has already been applied", m, latest)
+		}
+		pending = append(pending, m)
+	}
+	return pending, nil
+}
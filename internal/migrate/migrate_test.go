@@ -0,0 +1,156 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package migrate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles creates a temporary directory containing the specified files
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+var findTests = []struct {
+	files    map[string]string
+	versions []int
+	err      bool
+}{
+	{map[string]string{}, []int{}, false},
+	{map[string]string{"V2__b.mqsc": "B", "V001__a.mqsc": "A", "README.md": "Not a migration"}, []int{1, 2}, false},
+	{map[string]string{"V010__c.mqsc": "C", "V002__b.mqsc": "B"}, []int{2, 10}, false},
+	{map[string]string{"V1__a.mqsc": "A", "V001__b.mqsc": "B"}, nil, true},
+	{map[string]string{"create_queues.mqsc": "A"}, nil, true},
+	{map[string]string{"V1_a.mqsc": "A"}, nil, true},
+}
+
+func TestFind(t *testing.T) {
+	for _, table := range findTests {
+		dir := writeFiles(t, table.files)
+		defer os.RemoveAll(dir)
+		migrations, err := Find(dir)
+		if table.err {
+			if err == nil {
+				t.Errorf("Find(%v) - expected error, got %v", table.files, migrations)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Find(%v) - unexpected error: %v", table.files, err)
+			continue
+		}
+		versions := make([]int, len(migrations))
+		for i, m := range migrations {
+			versions[i] = m.Version
+		}
+		if !reflect.DeepEqual(versions, table.versions) {
+			t.Errorf("Find(%v) - expected %v, got %v", table.files, table.versions, versions)
+		}
+	}
+}
+
+func TestFindMissingDir(t *testing.T) {
+	migrations, err := Find("/does/not/exist")
+	if err != nil || len(migrations) != 0 {
+		t.Errorf("Find() - expected no migrations, got %v, %v", migrations, err)
+	}
+}
+
+func migration(version int, content string) Migration {
+	return Migration{Version: version, Path: fmt.Sprintf("V%03d__x.mqsc", version), Checksum: Checksum([]byte(content))}
+}
+
+func applied(version int, content string) Applied {
+	return Applied{Version: version, Checksum: Checksum([]byte(content))}
+}
+
+var pendingTests = []struct {
+	migrations []Migration
+	applied    []Applied
+	pending    []int
+	err        bool
+}{
+	{[]Migration{migration(1, "A"), migration(2, "B")}, []Applied{}, []int{1, 2}, false},
+	{[]Migration{migration(1, "A"), migration(2, "B")}, []Applied{applied(1, "A")}, []int{2}, false},
+	{[]Migration{migration(1, "A"), migration(2, "B")}, []Applied{applied(1, "A"), applied(2, "B")}, []int{}, false},
+	// An applied migration has been edited
+	{[]Migration{migration(1, "A2"), migration(2, "B")}, []Applied{applied(1, "A")}, nil, true},
+	// An applied migration has been removed
+	{[]Migration{migration(2, "B")}, []Applied{applied(1, "A")}, nil, true},
+	// A new migration is older than one which has been applied
+	{[]Migration{migration(1, "A"), migration(2, "B")}, []Applied{applied(2, "B")}, nil, true},
+}
+
+func TestPending(t *testing.T) {
+	for _, table := range pendingTests {
+		s := &State{Applied: table.applied}
+		pending, err := s.Pending(table.migrations)
+		if table.err {
+			if err == nil {
+				t.Errorf("Pending(%v) - expected error, got %v", table.migrations, pending)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Pending(%v) - unexpected error: %v", table.migrations, err)
+			continue
+		}
+		versions := make([]int, len(pending))
+		for i, m := range pending {
+			versions[i] = m.Version
+		}
+		if !reflect.DeepEqual(versions, table.pending) {
+			t.Errorf("Pending(%v) - expected %v, got %v", table.migrations, table.pending, versions)
+		}
+	}
+}
+
+func TestStateSaveLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{})
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "migrations.json")
+	s, err := LoadState(path)
+	if err != nil || len(s.Applied) != 0 {
+		t.Fatalf("LoadState() - expected empty state, got %v, %v", s, err)
+	}
+	s.Record(migration(1, "A"))
+	err = s.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Applied) != 1 || loaded.Applied[0].Checksum != Checksum([]byte("A")) || !loaded.Applied[0].AppliedAt.Equal(s.Applied[0].AppliedAt) {
+		t.Errorf("LoadState() - expected %v, got %v", s.Applied, loaded.Applied)
+	}
+}
@@ -0,0 +1,357 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mqini contains code to read, edit and write the stanza-format
+// configuration files used by MQ, such as mqs.ini and qm.ini.  Comments,
+// ordering and repeated stanzas are kept when the file is written back.
+package mqini
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// defaultIndent is used for new keys, when a stanza has no other keys
+const defaultIndent string = "   "
+
+// line is a single line in a stanza, which is either a key and value, or
+// a comment or blank line
+type line struct {
+	// text is the original text of the line, which is used when writing the
+	// file if the line hasn't been changed
+	text    string
+	key     string
+	value   string
+	indent  string
+	isKey   bool
+	changed bool
+}
+
+func (l *line) String() string {
+	if l.isKey && l.changed {
+		return l.indent + l.key + "=" + l.value
+	}
+	return l.text
+}
+
+// Stanza is a named group of keys, such as "Log:" in qm.ini
+type Stanza struct {
+	Name string
+	// text is the original text of the stanza's first line
+	text  string
+	lines []*line
+}
+
+// NewStanza returns an empty stanza
+func NewStanza(name string) *Stanza {
+	return &Stanza{Name: name, text: name + ":"}
+}
+
+// find returns the line holding a key, or nil if the key isn't set.  Keys
+// are matched without regard to case.
+func (s *Stanza) find(key string) *line {
+	for _, l := range s.lines {
+		if l.isKey && strings.EqualFold(l.key, key) {
+			return l
+		}
+	}
+	return nil
+}
+
+// Get returns the value of a key, and whether the key is set
+func (s *Stanza) Get(key string) (string, bool) {
+	l := s.find(key)
+	if l == nil {
+		return "", false
+	}
+	return l.value, true
+}
+
+// Keys returns the names of the keys in the stanza, in order
+func (s *Stanza) Keys() []string {
+	keys := make([]string, 0)
+	for _, l := range s.lines {
+		if l.isKey {
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// Set sets the value of a key.  An existing key is changed in place,
+// otherwise the key is added after the last key in the stanza.
+func (s *Stanza) Set(key string, value string) {
+	l := s.find(key)
+	if l != nil {
+		if l.value != value {
+			l.value = value
+			l.changed = true
+		}
+		return
+	}
+	last := -1
+	indent := defaultIndent
+	for i, l := range s.lines {
+		if l.isKey {
+			last = i
+			indent = l.indent
+		}
+	}
+	l = &line{key: key, value: value, indent: indent, isKey: true, changed: true}
+	s.lines = append(s.lines, nil)
+	copy(s.lines[last+2:], s.lines[last+1:])
+	s.lines[last+1] = l
+}
+
+// Delete removes a key from the stanza
+func (s *Stanza) Delete(key string) {
+	for i, l := range s.lines {
+		if l.isKey && strings.EqualFold(l.key, key) {
+			s.lines = append(s.lines[:i], s.lines[i+1:]...)
+			return
+		}
+	}
+}
+
+// label identifies the stanza in messages, including its name key if it has
+// one, such as "ApiExitLocal(Name=MyExit)"
+func (s *Stanza) label() string {
+	name, ok := s.Get("Name")
+	if !ok {
+		return s.Name
+	}
+	return fmt.Sprintf("%v(Name=%v)", s.Name, name)
+}
+
+// File is a parsed INI file
+type File struct {
+	// header holds the comments and blank lines before the first stanza
+	header  []string
+	stanzas []*Stanza
+}
+
+// Parse parses the contents of an INI file.  Lines starting with "#" or ";"
+// are comments.  Each stanza starts with its name, followed by a colon, and
+// is followed by its keys, in the form "key=value".
+func Parse(data []byte) (*File, error) {
+	f := &File{}
+	var current *Stanza
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	n := 0
+	for scanner.Scan() {
+		n++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";"):
+			if current == nil {
+				f.header = append(f.header, text)
+			} else {
+				current.lines = append(current.lines, &line{text: text})
+			}
+		case strings.Contains(trimmed, "="):
+			if current == nil {
+				return nil, fmt.Errorf("line %v: key outside of a stanza: %v", n, trimmed)
+			}
+			parts := strings.SplitN(trimmed, "=", 2)
+			key := strings.TrimSpace(parts[0])
+			if key == "" {
+				return nil, fmt.Errorf("line %v: missing key: %v", n, trimmed)
+			}
+			current.lines = append(current.lines, &line{
+				text:   text,
+				key:    key,
+				value:  strings.TrimSpace(parts[1]),
+				indent: text[:len(text)-len(strings.TrimLeft(text, " \t"))],
+				isKey:  true,
+			})
+		case strings.HasSuffix(trimmed, ":"):
+			current = &Stanza{Name: strings.TrimSpace(strings.TrimSuffix(trimmed, ":")), text: text}
+			f.stanzas = append(f.stanzas, current)
+		default:
+			return nil, fmt.Errorf("line %v: expected a stanza or a key: %v", n, trimmed)
+		}
+	}
+	return f, scanner.Err()
+}
+
+// Load reads and parses an INI file
+func Load(path string) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	return f, nil
+}
+
+// Bytes returns the contents of the file
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, h := range f.header {
+		buf.WriteString(h + "\n")
+	}
+	for _, s := range f.stanzas {
+		buf.WriteString(s.text + "\n")
+		for _, l := range s.lines {
+			buf.WriteString(l.String() + "\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+// Save writes the file, keeping the permissions and owner of any existing
+// file.  The file is written to a temporary file in the same directory, which
+// then replaces the file atomically, so that the file isn't left incomplete
+// if the container is stopped while writing it.
+func (f *File) Save(path string) error {
+	mode := os.FileMode(0644)
+	fi, err := os.Stat(path)
+	if err == nil {
+		mode = fi.Mode()
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	// Remove the temporary file if anything fails
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(f.Bytes())
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmp.Name(), mode)
+	if err != nil {
+		return err
+	}
+	if fi != nil {
+		if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+			err = os.Chown(tmp.Name(), int(stat.Uid), int(stat.Gid))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Stanzas returns the stanzas with the specified name, in order.  If the
+// name is empty, then all the stanzas are returned.
+func (f *File) Stanzas(name string) []*Stanza {
+	stanzas := make([]*Stanza, 0)
+	for _, s := range f.stanzas {
+		if name == "" || strings.EqualFold(s.Name, name) {
+			stanzas = append(stanzas, s)
+		}
+	}
+	return stanzas
+}
+
+// Stanza returns the first stanza with the specified name, or nil if there
+// isn't one
+func (f *File) Stanza(name string) *Stanza {
+	stanzas := f.Stanzas(name)
+	if len(stanzas) == 0 {
+		return nil
+	}
+	return stanzas[0]
+}
+
+// AddStanza adds a stanza at the end of the file
+func (f *File) AddStanza(s *Stanza) {
+	f.stanzas = append(f.stanzas, s)
+}
+
+// Change is a change made to a file by Merge
+type Change struct {
+	// Stanza identifies the stanza, such as "Log" or
+	// "ApiExitLocal(Name=MyExit)"
+	Stanza string
+	Key    string
+	// Old is the previous value of the key, which is empty if the key was
+	// added
+	Old   string
+	New   string
+	Added bool
+}
+
+func (c Change) String() string {
+	if c.Added {
+		return fmt.Sprintf("%v: %v=%v (added)", c.Stanza, c.Key, c.New)
+	}
+	return fmt.Sprintf("%v: %v=%v (was %v)", c.Stanza, c.Key, c.New, c.Old)
+}
+
+// match returns the stanza which an override stanza applies to, or nil if
+// it should be added as a new stanza.  Stanzas which can be repeated, such
+// as "ApiExitLocal", are identified by their "Name" key, so an override with
+// a name applies to the stanza with the same name.  Otherwise, the override
+// applies to the first stanza with the same stanza name.
+func (f *File) match(override *Stanza) *Stanza {
+	name, named := override.Get("Name")
+	for _, s := range f.Stanzas(override.Name) {
+		if !named {
+			return s
+		}
+		if v, ok := s.Get("Name"); ok && v == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Merge applies the keys from each stanza in the overrides to the matching
+// stanza in the file, adding any stanzas which don't match.  The changes
+// made are returned, in order.
+func (f *File) Merge(overrides *File) []Change {
+	changes := make([]Change, 0)
+	for _, o := range overrides.stanzas {
+		s := f.match(o)
+		if s == nil {
+			s = NewStanza(o.Name)
+			f.AddStanza(s)
+		}
+		for _, l := range o.lines {
+			if !l.isKey {
+				continue
+			}
+			old, ok := s.Get(l.key)
+			if ok && old == l.value {
+				continue
+			}
+			s.Set(l.key, l.value)
+			// Report the key using the case in the file being changed
+			key := s.find(l.key).key
+			changes = append(changes, Change{Stanza: s.label(), Key: key, Old: old, New: l.value, Added: !ok})
+		}
+	}
+	return changes
+}
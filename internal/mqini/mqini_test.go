@@ -0,0 +1,211 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mqini
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const qmINI = `#*******************************************************************#
+#* Module Name: qm.ini                                             *#
+#*******************************************************************#
+ExitPath:
+   ExitsDefaultPath=/var/mqm/exits
+   ExitsDefaultPath64=/var/mqm/exits64
+#*                                                                 *#
+Log:
+   LogPrimaryFiles=3
+   LogSecondaryFiles=2
+   LogFilePages=4096
+   LogType=CIRCULAR
+; Channels are configured below
+Channels:
+   MaxChannels=100
+ApiExitLocal:
+   Name=ExitA
+   Sequence=1
+ApiExitLocal:
+   Name=ExitB
+   Sequence=2
+Channels:
+   MaxActiveChannels=50
+`
+
+func TestRoundTrip(t *testing.T) {
+	f, err := Parse([]byte(qmINI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(f.Bytes())
+	if s != qmINI {
+		t.Errorf("Bytes() - expected %q, got %q", qmINI, s)
+	}
+	if len(f.Stanzas("Channels")) != 2 {
+		t.Errorf("Stanzas(Channels) - expected 2, got %v", len(f.Stanzas("Channels")))
+	}
+	if len(f.Stanzas("")) != 6 {
+		t.Errorf("Stanzas() - expected 6, got %v", len(f.Stanzas("")))
+	}
+	keys := f.Stanza("Log").Keys()
+	expected := []string{"LogPrimaryFiles", "LogSecondaryFiles", "LogFilePages", "LogType"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Keys() - expected %v, got %v", expected, keys)
+	}
+}
+
+var parseErrorTests = []string{
+	"Key=Value\n",
+	"Log:\n   =3\n",
+	"Log:\n   LogPrimaryFiles\n",
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, table := range parseErrorTests {
+		_, err := Parse([]byte(table))
+		if err == nil {
+			t.Errorf("Parse(%q) - expected error", table)
+		}
+	}
+}
+
+var setTests = []struct {
+	in  string
+	key string
+	val string
+	out string
+}{
+	{"Log:\n   LogPrimaryFiles=3\n", "LogPrimaryFiles", "10", "Log:\n   LogPrimaryFiles=10\n"},
+	{"Log:\n   LogPrimaryFiles=3\n", "logprimaryfiles", "3", "Log:\n   LogPrimaryFiles=3\n"},
+	{"Log:\n\tLogPrimaryFiles=3\n# Comment\nX:\n", "LogType", "LINEAR", "Log:\n\tLogPrimaryFiles=3\n\tLogType=LINEAR\n# Comment\nX:\n"},
+	{"Log:\n", "LogType", "LINEAR", "Log:\n   LogType=LINEAR\n"},
+}
+
+func TestSet(t *testing.T) {
+	for _, table := range setTests {
+		f, err := Parse([]byte(table.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Stanza("Log").Set(table.key, table.val)
+		s := string(f.Bytes())
+		if s != table.out {
+			t.Errorf("Set(%v, %v) - expected %q, got %q", table.key, table.val, table.out, s)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	f, err := Parse([]byte("Log:\n   LogPrimaryFiles=3\n   LogType=CIRCULAR\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Stanza("Log").Delete("LogPrimaryFiles")
+	expected := "Log:\n   LogType=CIRCULAR\n"
+	if string(f.Bytes()) != expected {
+		t.Errorf("Delete() - expected %q, got %q", expected, f.Bytes())
+	}
+}
+
+var mergeTests = []struct {
+	overrides string
+	changes   []string
+	out       string
+}{
+	{
+		"Log:\n   LogPrimaryFiles=10\n   LogBufferPages=512\n",
+		[]string{"Log: LogPrimaryFiles=10 (was 3)", "Log: LogBufferPages=512 (added)"},
+		"Log:\n   LogPrimaryFiles=10\n   LogBufferPages=512\nChannels:\n   MaxChannels=100\nApiExitLocal:\n   Name=ExitA\n   Sequence=1\n",
+	},
+	{
+		"Channels:\n   MaxChannels=100\n",
+		[]string{},
+		"Log:\n   LogPrimaryFiles=3\nChannels:\n   MaxChannels=100\nApiExitLocal:\n   Name=ExitA\n   Sequence=1\n",
+	},
+	{
+		"ApiExitLocal:\n   Name=ExitA\n   Sequence=5\nApiExitLocal:\n   Name=ExitB\n   Sequence=6\n",
+		[]string{"ApiExitLocal(Name=ExitA): Sequence=5 (was 1)", "ApiExitLocal(Name=ExitB): Name=ExitB (added)", "ApiExitLocal(Name=ExitB): Sequence=6 (added)"},
+		"Log:\n   LogPrimaryFiles=3\nChannels:\n   MaxChannels=100\nApiExitLocal:\n   Name=ExitA\n   Sequence=5\nApiExitLocal:\n   Name=ExitB\n   Sequence=6\n",
+	},
+}
+
+func TestMerge(t *testing.T) {
+	for _, table := range mergeTests {
+		f, err := Parse([]byte("Log:\n   LogPrimaryFiles=3\nChannels:\n   MaxChannels=100\nApiExitLocal:\n   Name=ExitA\n   Sequence=1\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		o, err := Parse([]byte(table.overrides))
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes := make([]string, 0)
+		for _, c := range f.Merge(o) {
+			changes = append(changes, c.String())
+		}
+		if !reflect.DeepEqual(changes, table.changes) {
+			t.Errorf("Merge(%q) - expected %v, got %v", table.overrides, table.changes, changes)
+		}
+		if string(f.Bytes()) != table.out {
+			t.Errorf("Merge(%q) - expected %q, got %q", table.overrides, table.out, f.Bytes())
+		}
+	}
+}
+
+// TestSave checks that the file is replaced, keeping its permissions, and
+// that no temporary files are left behind
+func TestSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mqini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "qm.ini")
+	err = ioutil.WriteFile(path, []byte(qmINI), 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Stanza("Log").Set("LogPrimaryFiles", "5")
+	err = f.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != string(f.Bytes()) {
+		t.Errorf("Save() - expected %q, got %q", f.Bytes(), buf)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0640 {
+		t.Errorf("Save() - expected mode 0640, got %v", fi.Mode().Perm())
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil || len(files) != 1 {
+		t.Errorf("Save() - expected only qm.ini in the directory, got %v, %v", len(files), err)
+	}
+}
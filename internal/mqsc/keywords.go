@@ -0,0 +1,199 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mqsc
+
+import (
+	"strings"
+)
+
+// words splits a list of keywords into a set
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+// verbs maps each command verb (and its synonyms) to the verb's full name
+var verbs = map[string]string{
+	"ALTER":   "ALTER",
+	"ALT":     "ALTER",
+	"CLEAR":   "CLEAR",
+	"DEFINE":  "DEFINE",
+	"DEF":     "DEFINE",
+	"DELETE":  "DELETE",
+	"DISPLAY": "DISPLAY",
+	"DIS":     "DISPLAY",
+	"END":     "END",
+	"PING":    "PING",
+	"PURGE":   "PURGE",
+	"REFRESH": "REFRESH",
+	"RESET":   "RESET",
+	"RESOLVE": "RESOLVE",
+	"RESUME":  "RESUME",
+	"SET":     "SET",
+	"START":   "START",
+	"STOP":    "STOP",
+	"SUSPEND": "SUSPEND",
+}
+
+// objectTypes maps each object type (and its synonyms) to the object type's
+// full name
+var objectTypes = map[string]string{
+	"QLOCAL":       "QLOCAL",
+	"QL":           "QLOCAL",
+	"QREMOTE":      "QREMOTE",
+	"QR":           "QREMOTE",
+	"QALIAS":       "QALIAS",
+	"QA":           "QALIAS",
+	"QMODEL":       "QMODEL",
+	"QM":           "QMODEL",
+	"QUEUE":        "QUEUE",
+	"Q":            "QUEUE",
+	"CHANNEL":      "CHANNEL",
+	"CHL":          "CHANNEL",
+	"LISTENER":     "LISTENER",
+	"LSTR":         "LISTENER",
+	"PROCESS":      "PROCESS",
+	"PRO":          "PROCESS",
+	"NAMELIST":     "NAMELIST",
+	"NL":           "NAMELIST",
+	"SERVICE":      "SERVICE",
+	"SVC":          "SERVICE",
+	"TOPIC":        "TOPIC",
+	"SUB":          "SUB",
+	"SUBSCRIPTION": "SUB",
+	"AUTHINFO":     "AUTHINFO",
+	"COMMINFO":     "COMMINFO",
+	"QMGR":         "QMGR",
+	"QMSTATUS":     "QMSTATUS",
+	"CHSTATUS":     "CHSTATUS",
+	"CHS":          "CHSTATUS",
+	"LSSTATUS":     "LSSTATUS",
+	"SVSTATUS":     "SVSTATUS",
+	"QSTATUS":      "QSTATUS",
+	"QS":           "QSTATUS",
+	"TPSTATUS":     "TPSTATUS",
+	"SBSTATUS":     "SBSTATUS",
+	"CONN":         "CONN",
+	"CHLAUTH":      "CHLAUTH",
+	"AUTHREC":      "AUTHREC",
+	"AUTHSERV":     "AUTHSERV",
+	"ENTAUTH":      "ENTAUTH",
+	"CLUSQMGR":     "CLUSQMGR",
+	"PUBSUB":       "PUBSUB",
+	"POLICY":       "POLICY",
+	"CLUSTER":      "CLUSTER",
+	"SECURITY":     "SECURITY",
+	"QSTATS":       "QSTATS",
+	"TOPICSTR":     "TOPICSTR",
+	"APSTATUS":     "APSTATUS",
+	"LOG":          "LOG",
+	"CHINIT":       "CHINIT",
+}
+
+// verbObjects lists the object types which can be used with each verb
+var verbObjects = map[string]map[string]bool{
+	"DEFINE":  words("QLOCAL QREMOTE QALIAS QMODEL CHANNEL LISTENER PROCESS NAMELIST SERVICE TOPIC SUB AUTHINFO COMMINFO"),
+	"ALTER":   words("QLOCAL QREMOTE QALIAS QMODEL CHANNEL LISTENER PROCESS NAMELIST SERVICE TOPIC SUB AUTHINFO COMMINFO QMGR"),
+	"DELETE":  words("QLOCAL QREMOTE QALIAS QMODEL CHANNEL LISTENER PROCESS NAMELIST SERVICE TOPIC SUB AUTHINFO COMMINFO AUTHREC POLICY"),
+	"DISPLAY": words("QLOCAL QREMOTE QALIAS QMODEL QUEUE CHANNEL LISTENER PROCESS NAMELIST SERVICE TOPIC SUB AUTHINFO COMMINFO QMGR QMSTATUS CHSTATUS LSSTATUS SVSTATUS QSTATUS TPSTATUS SBSTATUS CONN CHLAUTH AUTHREC AUTHSERV ENTAUTH CLUSQMGR PUBSUB POLICY APSTATUS"),
+	"SET":     words("CHLAUTH AUTHREC POLICY LOG"),
+	"START":   words("CHANNEL LISTENER SERVICE CHINIT"),
+	"STOP":    words("CHANNEL LISTENER SERVICE CONN CHINIT"),
+	"PING":    words("CHANNEL QMGR"),
+	"RESET":   words("CHANNEL CLUSTER QMGR QSTATS"),
+	"RESOLVE": words("CHANNEL"),
+	"RESUME":  words("QMGR"),
+	"SUSPEND": words("QMGR"),
+	"REFRESH": words("CLUSTER QMGR SECURITY"),
+	"CLEAR":   words("QLOCAL TOPICSTR"),
+	"PURGE":   words("CHANNEL"),
+	"END":     words(""),
+}
+
+// namedObjects are the object types which must have a name when defined,
+// along with the maximum length of the name
+var namedObjects = map[string]int{
+	"QLOCAL":   48,
+	"QREMOTE":  48,
+	"QALIAS":   48,
+	"QMODEL":   48,
+	"CHANNEL":  20,
+	"LISTENER": 48,
+	"PROCESS":  48,
+	"NAMELIST": 48,
+	"SERVICE":  48,
+	"TOPIC":    48,
+	"SUB":      10240,
+	"AUTHINFO": 48,
+	"COMMINFO": 48,
+}
+
+// parameters holds the names of all the parameters which can be used when
+// defining, altering, setting or controlling objects
+var parameters = words(`
+	ACTION ALL CMDSCOPE CUSTOM DESCR FORCE LIKE NOREPLACE QSGDISP REPLACE TYPE WHERE
+
+	ACCTQ BOQNAME BOTHRESH CAPEXPRY CFSTRUCT CLCHNAME CLUSNL CLUSTER CLWLPRTY CLWLRANK CLWLUSEQ
+	DEFBIND DEFPRESP DEFPRTY DEFPSIST DEFREADA DEFSOPT DEFTYPE DISTL GET HARDENBO IMGRCOVQ INDXTYPE
+	INITQ MAXDEPTH MAXMSGL MONQ MSGDLVSQ NOHARDENBO NOSHARE NOTRIGGER NPMCLASS PROCESS PROPCTL PUT
+	QDEPTHHI QDEPTHLO QDPHIEV QDPLOEV QDPMAXEV QSVCIEV QSVCINT RETINTVL RNAME RQMNAME SCOPE SHARE
+	STATQ STGCLASS STREAMQ STRMQOS TARGET TARGQ TARGTYPE TRIGDATA TRIGDPTH TRIGGER TRIGMPRI
+	TRIGTYPE USAGE XMITQ PURGE NOPURGE
+
+	AFFINITY AMQPKA BATCHHB BATCHINT BATCHLIM BATCHSZ CERTLABL CHLTYPE CLNTWGHT CLWLWGHT COMPHDR
+	COMPMSG CONNAME CONVERT DEFRECON DISCINT HBINT JAASCFG KAINT LOCLADDR LONGRTY LONGTMR MAXINST
+	MAXINSTC MCANAME MCATYPE MCAUSER MODENAME MONCHL MRDATA MREXIT MRRTY MRTMR MSGDATA MSGEXIT
+	NETPRTY NPMSPEED PASSWORD PORT PUTAUT QMNAME RCVDATA RCVEXIT RESETSEQ SCYDATA SCYEXIT SENDDATA
+	SENDEXIT SEQWRAP SHARECNV SHORTRTY SHORTTMR SPLPROT SSLCAUTH SSLCIPH SSLPEER STATCHL TMPMODEL
+	TMPQPRFX TPNAME TPROOT TRPTYPE USECLTID USEDLQ USERID CHLDISP MODE SEQNUM CLIENTID CONN
+	CHANNEL STATUS
+
+	ADAPTER BACKLOG COMMANDS CONTROL IGNSTATE IPADDR LOCLNAME NTBNAMES SESSIONS SOCKET
+
+	APPLICID APPLTYPE ENVRDATA USERDATA NAMES NLTYPE
+
+	SERVTYPE STARTARG STARTCMD STDERR STDOUT STOPARG STOPCMD
+
+	CLROUTE COMMINFO DURSUB MCAST MDURMDL MNDURMDL NPMSGDLV PMSGDLV PROXYSUB PUB PUBSCOPE SUB
+	SUBSCOPE TOPICSTR WILDCARD
+
+	DEST DESTCLAS DESTCORL DESTQMGR EXPIRY PSPROP PUBACCT PUBAPPID PUBPRTY REQONLY SELECTOR
+	SUBID SUBLEVEL SUBUSER TOPICOBJ VARUSER WSCHEMA
+
+	ADOPTCTX AUTHENMD AUTHORMD AUTHTYPE BASEDNG BASEDNU CHCKCLNT CHCKLOCL CLASSGRP CLASSUSR
+	FAILDLAY FINDGRP GRPFIELD LDAPPWD LDAPUSER NESTGRP OCSPURL SECCOMM SHORTUSR USRFIELD
+
+	BRIDGE CCSID COMMEV ENCODING GRPADDR MCHBINT MCPROP MONINT MSGHIST NSUBHIST
+
+	ACCTCONO ACCTINT ACCTMQI ACTCHL ACTIVREC ACTVCONO ACTVTRC AUTHOREV CERTVPOL CHAD CHADEV
+	CHADEXIT CHLAUTH CHLEV CLWLDATA CLWLEXIT CLWLLEN CLWLMRUC CMDEV CONFIGEV CONNAUTH DEADQ
+	DEFCLXQ DEFXMITQ IMGINTVL IMGLOGLN IMGRCOVO IMGSCHED INHIBTEV IPADDRV LOCALEV LOGGEREV MARKINT
+	MAXHANDS MAXPROPL MAXPRTY MAXUMSGS MONACLS PARENT PERFMEV PSCLUS PSMODE PSNPMSG PSNPRES
+	PSRTYCNT PSSYNCPT REMOTEEV REPOS REPOSNL REVDNS ROUTEREC SCHINIT SCMDSERV SPLCAP SSLCRLNL
+	SSLCRYP SSLEV SSLFIPS SSLKEYR SSLRKEYC STATACLS STATINT STATMQI STRSTPEV SUITEB TREELIFE
+	TRIGINT XRCAP
+
+	ADDRESS ADDRLIST CLNTUSER MATCH SSLCERTI USERLIST USERSRC WARN
+
+	AUTHADD AUTHRMV GROUP OBJTYPE PRINCIPAL PROFILE SERVCOMP
+
+	ENFORCE ENCALG SIGNALG SIGNER RECIP TOLERATE KEYREUSE
+
+	QMID QMNAME SECURITY TOPIC
+`)
@@ -0,0 +1,268 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mqsc
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Diagnostic is a problem found in an MQSC script
+type Diagnostic struct {
+	// Line is the line number in the script where the command starts
+	Line    int
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%v: %v", d.Line, d.Message)
+}
+
+var (
+	keywordPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+	namePattern    = regexp.MustCompile(`^[A-Za-z0-9._/%]+$`)
+)
+
+// ValidName returns true if a name only contains the characters allowed in
+// the names of MQ objects
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// param is a keyword in a command, with an optional value in parentheses,
+// such as "MAXDEPTH(5000)" or "REPLACE"
+type param struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+// checkQuotes checks that quotes in a value only surround whole items, such
+// as "'A','B'" or "DESCR EQ 'X'"
+func checkQuotes(name string, value string) error {
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\'' {
+			continue
+		}
+		if i > 0 && !strings.ContainsRune(" ,", rune(value[i-1])) {
+			return fmt.Errorf("unexpected quote in the value of %v", name)
+		}
+		// Find the closing quote, skipping doubled quotes
+		for i++; i < len(value); i++ {
+			if value[i] == '\'' {
+				if i+1 < len(value) && value[i+1] == '\'' {
+					i++
+					continue
+				}
+				break
+			}
+		}
+		if i+1 < len(value) && !strings.ContainsRune(" ,", rune(value[i+1])) {
+			return fmt.Errorf("unexpected characters after quoted string in the value of %v", name)
+		}
+	}
+	return nil
+}
+
+// readValue reads a value, starting after the opening parenthesis, and
+// returns the value and the position after the closing parenthesis
+func readValue(name string, text string, start int) (string, int, error) {
+	quoted := false
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '\'':
+			if quoted && i+1 < len(text) && text[i+1] == '\'' {
+				i++
+				continue
+			}
+			quoted = !quoted
+		case '(':
+			if !quoted {
+				return "", 0, fmt.Errorf("parentheses in the value of %v must be quoted", name)
+			}
+		case ')':
+			if !quoted {
+				value := text[start:i]
+				return value, i + 1, checkQuotes(name, value)
+			}
+		}
+	}
+	if quoted {
+		return "", 0, fmt.Errorf("unterminated quoted string in the value of %v", name)
+	}
+	return "", 0, fmt.Errorf("missing ')' after the value of %v", name)
+}
+
+// tokenize splits a command into its keywords and values.  Keywords can be
+// separated by blanks or commas.
+func tokenize(text string) ([]param, error) {
+	params := make([]param, 0)
+	i := 0
+	for i < len(text) {
+		switch text[i] {
+		case ' ', '\t', ',':
+			i++
+			continue
+		case '\'':
+			return nil, fmt.Errorf("unexpected quoted string")
+		case '(', ')':
+			return nil, fmt.Errorf("unexpected '%c'", text[i])
+		}
+		start := i
+		for i < len(text) && !strings.ContainsRune(" \t,()'", rune(text[i])) {
+			i++
+		}
+		p := param{name: text[start:i]}
+		if i < len(text) && text[i] == '(' {
+			var err error
+			p.value, i, err = readValue(p.name, text, i+1)
+			if err != nil {
+				return nil, err
+			}
+			p.hasValue = true
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// unquoteName removes the quotes from an object name
+func unquoteName(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1)
+	}
+	return s
+}
+
+// checkName checks the name of an object being defined
+func checkName(object string, p param) error {
+	max, ok := namedObjects[object]
+	if !ok {
+		return nil
+	}
+	name := unquoteName(p.value)
+	if name == "" {
+		return fmt.Errorf("DEFINE %v requires a name", object)
+	}
+	if len(name) > max {
+		return fmt.Errorf("%v name %v is longer than %v characters", object, name, max)
+	}
+	if object != "SUB" && !ValidName(name) {
+		return fmt.Errorf("%v name %v contains characters which aren't allowed", object, name)
+	}
+	return nil
+}
+
+// lintCommand checks a single command
+func lintCommand(text string) []string {
+	params, err := tokenize(text)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	word := strings.ToUpper(params[0].name)
+	if strings.HasPrefix(word, "*") {
+		return []string{"comments must start with '*' in the first column"}
+	}
+	verb := verbs[word]
+	if verb == "" || params[0].hasValue {
+		return []string{fmt.Sprintf("unknown command %v", params[0].name)}
+	}
+	objects := verbObjects[verb]
+	if len(objects) == 0 {
+		if len(params) > 1 {
+			return []string{fmt.Sprintf("unexpected parameters after %v", verb)}
+		}
+		return nil
+	}
+	if len(params) < 2 {
+		return []string{fmt.Sprintf("%v requires an object type", verb)}
+	}
+	object := objectTypes[strings.ToUpper(params[1].name)]
+	if !objects[object] {
+		return []string{fmt.Sprintf("%v is not a valid object type for %v", params[1].name, verb)}
+	}
+	problems := make([]string, 0)
+	if verb == "DEFINE" {
+		err = checkName(object, params[1])
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if verb == "DISPLAY" {
+		// Any attribute can be used to filter or select the output of DISPLAY
+		return problems
+	}
+	seen := make(map[string]bool)
+	for _, p := range params[2:] {
+		name := strings.ToUpper(p.name)
+		switch {
+		case !keywordPattern.MatchString(name):
+			problems = append(problems, fmt.Sprintf("invalid parameter %v", p.name))
+		case !parameters[name]:
+			problems = append(problems, fmt.Sprintf("unknown parameter %v", name))
+		case seen[name]:
+			problems = append(problems, fmt.Sprintf("parameter %v specified more than once", name))
+		}
+		seen[name] = true
+	}
+	return problems
+}
+
+// ContinuedAtEnd returns the line where the last command starts, if that
+// command is continued past the end of the script
+func ContinuedAtEnd(script string) (int, bool) {
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	line, start := 0, 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		if start == 0 && (strings.HasPrefix(text, "*") || strings.TrimSpace(text) == "") {
+			continue
+		}
+		if strings.HasSuffix(text, "+") || strings.HasSuffix(text, "-") {
+			if start == 0 {
+				start = line
+			}
+			continue
+		}
+		start = 0
+	}
+	return start, start != 0
+}
+
+// Lint checks the syntax of an MQSC script, without running it.  It checks
+// the command verbs, object types and parameter names, that quotes and
+// parentheses are balanced, and that the last command isn't continued past
+// the end of the script.  Values aren't checked.
+func Lint(script string) []Diagnostic {
+	diags := make([]Diagnostic, 0)
+	for _, c := range Split(script) {
+		for _, msg := range lintCommand(c.Text) {
+			diags = append(diags, Diagnostic{Line: c.Line, Message: msg})
+		}
+	}
+	line, ok := ContinuedAtEnd(script)
+	if ok {
+		diags = append(diags, Diagnostic{Line: line, Message: "command is continued past the end of the file"})
+	}
+	return diags
+}
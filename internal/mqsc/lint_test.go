@@ -0,0 +1,65 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mqsc
+
+import (
+	"reflect"
+	"testing"
+)
+
+var lintTests = []struct {
+	script string
+	diags  []Diagnostic
+}{
+	{"", []Diagnostic{}},
+	{"* Comment\nDEFINE QLOCAL(A) MAXDEPTH(5000) REPLACE\n", []Diagnostic{}},
+	{"def ql('a.b') descr('It''s (mine)') defpsist(yes)", []Diagnostic{}},
+	{"DEFINE CHANNEL(A) CHLTYPE(SDR) CONNAME('host(1414)') XMITQ(X),TRPTYPE(TCP)", []Diagnostic{}},
+	{"SET CHLAUTH('*') TYPE(ADDRESSMAP) ADDRESS('*') USERSRC(NOACCESS) ACTION(REPLACE)", []Diagnostic{}},
+	{"SET AUTHREC PROFILE('APP.**') OBJTYPE(QUEUE) PRINCIPAL('app') AUTHADD(PUT,GET)", []Diagnostic{}},
+	{"ALTER QMGR CHLAUTH(DISABLED)\nREFRESH SECURITY TYPE(CONNAUTH)\nSTART LISTENER(L)\nEND", []Diagnostic{}},
+	{"DISPLAY QLOCAL(*) WHERE(CURDEPTH GT 0) CURDEPTH", []Diagnostic{}},
+	{"DEFINE QLOCAL(A) +\n  DESCR('Queue A')\nDEFINE QLOCAL(B) DESCR('Que-\nue B')", []Diagnostic{}},
+	{"DEFINE QLOCAL(A) MAXDE+\n  PTH(10)", []Diagnostic{}},
+	{"DEFINE QLOCAL(APP.QUE+\n UE) MAXDE-\nPTH(10)", []Diagnostic{}},
+	{"DEFINE QLOCAL(APP.QUE-\n UE)", []Diagnostic{{1, "QLOCAL name APP.QUE UE contains characters which aren't allowed"}}},
+	{"DEFINE QLOCAL(A) MAXDE-\n  PTH(10)", []Diagnostic{{1, "unknown parameter MAXDE"}, {1, "unknown parameter PTH"}}},
+	{"DEFINE QLOCAL(A)\nDEFNE QLOCAL(B)", []Diagnostic{{2, "unknown command DEFNE"}}},
+	{"DEFINE QLOCL(A)", []Diagnostic{{1, "QLOCL is not a valid object type for DEFINE"}}},
+	{"START QLOCAL(A)", []Diagnostic{{1, "QLOCAL is not a valid object type for START"}}},
+	{"DISPLAY", []Diagnostic{{1, "DISPLAY requires an object type"}}},
+	{"DEFINE QLOCAL(A) MAXDEPT(5)", []Diagnostic{{1, "unknown parameter MAXDEPT"}}},
+	{"DEFINE QLOCAL(A) MAXDEPTH(5) maxdepth(6)", []Diagnostic{{1, "parameter MAXDEPTH specified more than once"}}},
+	{"DEFINE QLOCAL", []Diagnostic{{1, "DEFINE QLOCAL requires a name"}}},
+	{"DEFINE QLOCAL(A*B)", []Diagnostic{{1, "QLOCAL name A*B contains characters which aren't allowed"}}},
+	{"DEFINE CHANNEL(ABCDEFGHIJKLMNOPQRSTU) CHLTYPE(SVRCONN)", []Diagnostic{{1, "CHANNEL name ABCDEFGHIJKLMNOPQRSTU is longer than 20 characters"}}},
+	{"DEFINE QLOCAL(A) DESCR('Queue A)", []Diagnostic{{1, "unterminated quoted string in the value of DESCR"}}},
+	{"DEFINE QLOCAL(A) DESCR('Queue'A)", []Diagnostic{{1, "unexpected characters after quoted string in the value of DESCR"}}},
+	{"DEFINE QLOCAL(A) MAXDEPTH(5", []Diagnostic{{1, "missing ')' after the value of MAXDEPTH"}}},
+	{"DEFINE QLOCAL(A) MAXDEPTH(5))", []Diagnostic{{1, "unexpected ')'"}}},
+	{"DEFINE CHANNEL(A) CHLTYPE(SDR) CONNAME(host(1414))", []Diagnostic{{1, "parentheses in the value of CONNAME must be quoted"}}},
+	{" * Comment", []Diagnostic{{1, "comments must start with '*' in the first column"}}},
+	{"DEFINE QLOCAL(A)\n\nDEFINE QLOCAL(B) +\n  MAXDEPTH(5) -\n", []Diagnostic{{3, "command is continued past the end of the file"}}},
+}
+
+func TestLint(t *testing.T) {
+	for _, table := range lintTests {
+		diags := Lint(table.script)
+		if !reflect.DeepEqual(diags, table.diags) {
+			t.Errorf("Lint(%q) - expected %v, got %v", table.script, table.diags, diags)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mqsc contains code to split MQSC scripts into commands, and to
+// parse the output of runmqsc
+package mqsc
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Command is a single MQSC command from a script
+type Command struct {
+	// Line is the line number in the script where the command starts
+	Line int
+	// Text is the command, with any continuation characters removed
+	Text string
+}
+
+// Split splits an MQSC script into commands.  Comment lines (starting with
+// "*") and blank lines are ignored, and lines ending with "+" or "-" are
+// joined with the following line.  After "+", the command continues from the
+// first non-blank character of the next line, and after "-" it continues
+// from the first column, so that any leading blanks are kept.  Nothing is
+// added between the lines, so a continuation can be in the middle of a word.
+func Split(script string) []Command {
+	commands := make([]Command, 0)
+	var current *Command
+	// trim is true if leading blanks are removed from the next line
+	trim := true
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		if current == nil {
+			if strings.HasPrefix(text, "*") || strings.TrimSpace(text) == "" {
+				continue
+			}
+			current = &Command{Line: line}
+		}
+		if trim {
+			text = strings.TrimLeft(text, " \t")
+		}
+		switch {
+		case strings.HasSuffix(text, "+"):
+			current.Text += strings.TrimSuffix(text, "+")
+			trim = true
+		case strings.HasSuffix(text, "-"):
+			current.Text += strings.TrimSuffix(text, "-")
+			trim = false
+		default:
+			current.Text += text
+			current.Text = strings.TrimSpace(current.Text)
+			commands = append(commands, *current)
+			current = nil
+			trim = true
+		}
+	}
+	if current != nil && strings.TrimSpace(current.Text) != "" {
+		// The last command ended with a continuation character
+		current.Text = strings.TrimSpace(current.Text)
+		commands = append(commands, *current)
+	}
+	return commands
+}
+
+// Message is an MQ message written by runmqsc, such as
+// "AMQ8006I: IBM MQ queue created."
+type Message struct {
+	ID   string
+	Text string
+}
+
+// syntaxErrors are the informational messages which runmqsc writes when a
+// command has a syntax error
+var syntaxErrors = map[string]bool{
+	"AMQ8405I": true,
+	"AMQ8426I": true,
+	"AMQ8427I": true,
+}
+
+// Failed returns true if the message indicates that a command failed
+func (m Message) Failed() bool {
+	if syntaxErrors[m.ID] {
+		return true
+	}
+	switch m.ID[len(m.ID)-1:] {
+	case "E", "S", "T":
+		return true
+	}
+	return false
+}
+
+// Result is the output of runmqsc for a single command
+type Result struct {
+	// Number is the command number echoed by runmqsc, starting at 1
+	Number   int
+	Text     string
+	Messages []Message
+}
+
+// Failed returns true if any of the messages for the command indicate that
+// it failed
+func (r *Result) Failed() bool {
+	for _, m := range r.Messages {
+		if m.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Output is the parsed output of runmqsc
+type Output struct {
+	Results []*Result
+	// Messages holds any messages which weren't written for a command, such
+	// as an error connecting to the queue manager
+	Messages []Message
+	// Summary is true if the summary lines were found, in which case Read,
+	// SyntaxErrors and NotProcessed are set
+	Summary      bool
+	Read         int
+	SyntaxErrors int
+	NotProcessed int
+}
+
+// Failed returns the results for commands which failed
+func (o *Output) Failed() []*Result {
+	failed := make([]*Result, 0)
+	for _, r := range o.Results {
+		if r.Failed() {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+var (
+	echoPattern         = regexp.MustCompile(`^\s+(\d*)\s+:\s?(.*)$`)
+	messagePattern      = regexp.MustCompile(`^(AMQ\d{4}[A-Z]?):\s*(.*)$`)
+	readPattern         = regexp.MustCompile(`^(\w+) MQSC commands? read\.$`)
+	syntaxErrorPattern  = regexp.MustCompile(`^(\w+) commands? ha(?:s|ve) a syntax error\.$`)
+	notProcessedPattern = regexp.MustCompile(`^(\w+) valid MQSC commands? could not be processed\.$`)
+)
+
+// parseCount parses a count in a runmqsc summary line, such as "No", "One"
+// or "12"
+func parseCount(s string) int {
+	switch s {
+	case "No", "All":
+		return 0
+	case "One":
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// ParseOutput parses the output of runmqsc, when run without the "-e" flag,
+// so that each command is echoed before its messages
+func ParseOutput(out string) *Output {
+	o := &Output{
+		Results:  make([]*Result, 0),
+		Messages: make([]Message, 0),
+	}
+	var current *Result
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if m := echoPattern.FindStringSubmatch(line); m != nil {
+			if m[1] == "" {
+				// A continuation or comment line
+				if current != nil {
+					current.Text += "\n" + m[2]
+				}
+				continue
+			}
+			n, _ := strconv.Atoi(m[1])
+			if current == nil || current.Number != n {
+				current = &Result{Number: n, Text: m[2]}
+				o.Results = append(o.Results, current)
+			} else {
+				current.Text += "\n" + m[2]
+			}
+			continue
+		}
+		if m := messagePattern.FindStringSubmatch(line); m != nil {
+			msg := Message{ID: m[1], Text: m[2]}
+			if current != nil {
+				current.Messages = append(current.Messages, msg)
+			} else {
+				o.Messages = append(o.Messages, msg)
+			}
+			continue
+		}
+		if m := readPattern.FindStringSubmatch(line); m != nil {
+			o.Summary = true
+			o.Read = parseCount(m[1])
+			// Any further messages aren't for a command
+			current = nil
+		} else if m := syntaxErrorPattern.FindStringSubmatch(line); m != nil {
+			o.SyntaxErrors = parseCount(m[1])
+		} else if m := notProcessedPattern.FindStringSubmatch(line); m != nil {
+			o.NotProcessed = parseCount(m[1])
+		}
+	}
+	return o
+}
@@ -0,0 +1,135 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mqsc
+
+import (
+	"reflect"
+	"testing"
+)
+
+var splitTests = []struct {
+	script   string
+	commands []Command
+}{
+	{"", []Command{}},
+	{"DEFINE QLOCAL(A)", []Command{{1, "DEFINE QLOCAL(A)"}}},
+	{"* Comment\n\nDEFINE QLOCAL(A)\n  DEFINE QLOCAL(B)  \n", []Command{{3, "DEFINE QLOCAL(A)"}, {4, "DEFINE QLOCAL(B)"}}},
+	{"DEFINE QLOCAL(A) +\n  DESCR('Queue A')\nDEFINE QLOCAL(B)", []Command{{1, "DEFINE QLOCAL(A) DESCR('Queue A')"}, {3, "DEFINE QLOCAL(B)"}}},
+	{"DEFINE QLOCAL(A) DESCR('Que-\nue A')", []Command{{1, "DEFINE QLOCAL(A) DESCR('Queue A')"}}},
+	{"DEFINE QLOCAL(A) DESCR('Que-\n  ue A')", []Command{{1, "DEFINE QLOCAL(A) DESCR('Que  ue A')"}}},
+	{"DEFINE QLOCAL(A) MAXDE+\n  PTH(10)", []Command{{1, "DEFINE QLOCAL(A) MAXDEPTH(10)"}}},
+	{"DEFINE QLOCAL(APP.QUE+\n UE)", []Command{{1, "DEFINE QLOCAL(APP.QUEUE)"}}},
+	{"DEFINE QLOCAL(A)+\n  MAXDEPTH(10)", []Command{{1, "DEFINE QLOCAL(A)MAXDEPTH(10)"}}},
+	{"DEFINE QLOCAL(A) -\n  MAXDEPTH(10)", []Command{{1, "DEFINE QLOCAL(A)   MAXDEPTH(10)"}}},
+	{"DEFINE QLOCAL(A) +", []Command{{1, "DEFINE QLOCAL(A)"}}},
+}
+
+func TestSplit(t *testing.T) {
+	for _, table := range splitTests {
+		commands := Split(table.script)
+		if !reflect.DeepEqual(commands, table.commands) {
+			t.Errorf("Split(%q) - expected %v, got %v", table.script, table.commands, commands)
+		}
+	}
+}
+
+const successOutput string = `5724-H72 (C) Copyright IBM Corp. 1994, 2017.
+Starting MQSC for queue manager QM1.
+
+
+     1 : DEFINE QLOCAL(A) REPLACE
+AMQ8006I: IBM MQ queue created.
+     2 : DEFINE QLOCAL(B) +
+       :    REPLACE
+AMQ8006I: IBM MQ queue created.
+2 MQSC commands read.
+No commands have a syntax error.
+All valid MQSC commands were processed.
+`
+
+const failureOutput string = `5724-H72 (C) Copyright IBM Corp. 1994, 2017.
+Starting MQSC for queue manager QM1.
+
+
+     1 : DEFINE QLOCAL(A)
+AMQ8150E: IBM MQ object already exists.
+     2 : DEFINE QLOCL(B)
+AMQ8405I: Syntax error detected at or near end of command segment below:-
+DEFINE QLOCL(B
+
+AMQ8426I: Valid MQSC commands are:
+
+    ALTER
+    DEFINE
+     3 : DEFINE QLOCAL(C) REPLACE
+AMQ8006I: IBM MQ queue created.
+3 MQSC commands read.
+One command has a syntax error.
+One valid MQSC command could not be processed.
+`
+
+const noQueueManagerOutput string = `5724-H72 (C) Copyright IBM Corp. 1994, 2017.
+Starting MQSC for queue manager QM2.
+
+
+AMQ8118E: IBM MQ queue manager does not exist.
+`
+
+var parseOutputTests = []struct {
+	out          string
+	results      int
+	failed       []int
+	messages     int
+	summary      bool
+	read         int
+	syntaxErrors int
+	notProcessed int
+}{
+	{successOutput, 2, []int{}, 0, true, 2, 0, 0},
+	{failureOutput, 3, []int{1, 2}, 0, true, 3, 1, 1},
+	{noQueueManagerOutput, 0, []int{}, 1, false, 0, 0, 0},
+	{"", 0, []int{}, 0, false, 0, 0, 0},
+}
+
+func TestParseOutput(t *testing.T) {
+	for i, table := range parseOutputTests {
+		o := ParseOutput(table.out)
+		if len(o.Results) != table.results {
+			t.Errorf("ParseOutput(%v) - expected %v results, got %v", i, table.results, len(o.Results))
+		}
+		failed := make([]int, 0)
+		for _, r := range o.Failed() {
+			failed = append(failed, r.Number)
+		}
+		if !reflect.DeepEqual(failed, table.failed) {
+			t.Errorf("ParseOutput(%v) - expected failed commands %v, got %v", i, table.failed, failed)
+		}
+		if len(o.Messages) != table.messages {
+			t.Errorf("ParseOutput(%v) - expected %v other messages, got %v", i, table.messages, len(o.Messages))
+		}
+		if o.Summary != table.summary || o.Read != table.read || o.SyntaxErrors != table.syntaxErrors || o.NotProcessed != table.notProcessed {
+			t.Errorf("ParseOutput(%v) - expected summary %v %v/%v/%v, got %v %v/%v/%v", i, table.summary, table.read, table.syntaxErrors, table.notProcessed, o.Summary, o.Read, o.SyntaxErrors, o.NotProcessed)
+		}
+	}
+}
+
+func TestParseOutputText(t *testing.T) {
+	o := ParseOutput(successOutput)
+	expected := "DEFINE QLOCAL(B) +\n   REPLACE"
+	if o.Results[1].Text != expected {
+		t.Errorf("ParseOutput() - expected text %q, got %q", expected, o.Results[1].Text)
+	}
+}
@@ -18,29 +18,189 @@ limitations under the License.
 package name
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 )
 
-// sanitizeQueueManagerName removes any invalid characters from a queue manager name
-func sanitizeQueueManagerName(name string) string {
-	var re = regexp.MustCompile("[^a-zA-Z0-9._%/]")
-	return re.ReplaceAllString(name, "")
+// File is the file used to share the name of the queue manager being run by
+// runmqserver, which can differ from the name in the environment if an
+// existing queue manager has been adopted
+const File string = "/run/runmqserver/qmgrname"
+
+// MaxLength is the maximum length of a queue manager name
+const MaxLength int = 48
+
+// hashLength is the number of hexadecimal digits of the hash added to names
+// derived from a host name
+const hashLength int = 8
+
+// ErrorKind identifies why a queue manager name isn't valid
+type ErrorKind int
+
+// Reasons why a queue manager name isn't valid
+const (
+	EmptyName ErrorKind = iota
+	NameTooLong
+	InvalidCharacter
+	InvalidFirstCharacter
+)
+
+// InvalidNameError is returned when a queue manager name isn't valid
+type InvalidNameError struct {
+	Name string
+	Kind ErrorKind
+	// Char is the first character which isn't allowed, for InvalidCharacter
+	// and InvalidFirstCharacter errors
+	Char rune
+}
+
+func (e *InvalidNameError) Error() string {
+	switch e.Kind {
+	case EmptyName:
+		return "Invalid queue manager name: the name must not be empty"
+	case NameTooLong:
+		return fmt.Sprintf("Invalid queue manager name %v: the name must not be longer than %v characters", e.Name, MaxLength)
+	case InvalidCharacter:
+		return fmt.Sprintf("Invalid queue manager name %v: the character %q isn't allowed.  Names can contain letters, digits and the characters . _ %% /", e.Name, e.Char)
+	default:
+		return fmt.Sprintf("Invalid queue manager name %v: the name must start with a letter or a digit, not %q", e.Name, e.Char)
+	}
+}
+
+// validChar returns true if the character can be used in a queue manager name
+func validChar(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || strings.ContainsRune("._%/", c)
+}
+
+// validFirstChar returns true if a queue manager name can start with the
+// character
+func validFirstChar(c rune) bool {
+	return validChar(c) && !strings.ContainsRune("._%/", c)
+}
+
+// Validate checks that a queue manager name is valid.  Names can be up to 48
+// characters long, and can contain letters, digits and the characters
+// ".", "_", "%" and "/".  The first character must be a letter or a digit.
+// An *InvalidNameError is returned if the name isn't valid.
+func Validate(name string) error {
+	if name == "" {
+		return &InvalidNameError{Name: name, Kind: EmptyName}
+	}
+	for i, c := range name {
+		if !validChar(c) {
+			return &InvalidNameError{Name: name, Kind: InvalidCharacter, Char: c}
+		}
+		if i == 0 && !validFirstChar(c) {
+			return &InvalidNameError{Name: name, Kind: InvalidFirstCharacter, Char: c}
+		}
+	}
+	if len(name) > MaxLength {
+		return &InvalidNameError{Name: name, Kind: NameTooLong}
+	}
+	return nil
+}
+
+// FromHostname returns the queue manager name to use for a host name.
+// Dashes are replaced by underscores, which can't appear in host names, so
+// that names such as "my-qm" and "myqm" don't clash.  If any other changes
+// are needed (replacing other invalid characters, prefixing "QM" to fix an
+// invalid first character, or truncating the name to 48 characters), then a
+// hash of the host name is added, so that different host names still result
+// in different queue manager names.
+func FromHostname(hostname string) string {
+	changed := false
+	runes := make([]rune, 0, len(hostname))
+	for _, c := range hostname {
+		switch {
+		case c == '-':
+			runes = append(runes, '_')
+		case validChar(c):
+			runes = append(runes, c)
+		default:
+			runes = append(runes, '_')
+			changed = true
+		}
+	}
+	name := string(runes)
+	if name == "" || !validFirstChar(runes[0]) {
+		name = "QM" + name
+		changed = true
+	}
+	if !changed && len(name) <= MaxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(hostname))
+	suffix := "_" + hex.EncodeToString(sum[:])[:hashLength]
+	if len(name) > MaxLength-len(suffix) {
+		name = name[:MaxLength-len(suffix)]
+	}
+	return name + suffix
+}
+
+// legacyPattern matches the characters removed from host names by earlier
+// versions of FromHostname
+var legacyPattern = regexp.MustCompile("[^a-zA-Z0-9._%/]")
+
+// LegacyFromHostname returns the queue manager name which earlier versions
+// used for a host name, which had any invalid characters (including dashes)
+// removed.  This is used to find queue managers created by those versions.
+func LegacyFromHostname(hostname string) string {
+	return legacyPattern.ReplaceAllString(hostname, "")
 }
 
 // GetQueueManagerName resolves the queue manager name to use.  Resolved from
-// either an environment variable, or the hostname.
+// either an environment variable, or the hostname.  The environment variable
+// can be a template, such as "QM_ORDERS_{{ .Ordinal }}", which is rendered
+// first.  A name set in the environment must be valid, otherwise an
+// *InvalidNameError is returned.
 func GetQueueManagerName() (string, error) {
-	var name string
-	var err error
 	name, ok := os.LookupEnv("MQ_QMGR_NAME")
-	if !ok || name == "" {
-		name, err = os.Hostname()
+	if ok && isTemplate(name) {
+		data, err := newTemplateData()
 		if err != nil {
 			return "", err
 		}
-		name = sanitizeQueueManagerName(name)
+		return renderName(name, data)
+	}
+	if ok && name != "" {
+		err := Validate(name)
+		if err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	return FromHostname(hostname), nil
+}
+
+// WriteFile records the name of the queue manager being run
+func WriteFile(path string, name string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(name), 0644)
+}
+
+// ReadFile returns the name of the queue manager recorded by runmqserver.  If
+// no name has been recorded, then the name is resolved in the same way as
+// GetQueueManagerName.
+func ReadFile(path string) (string, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return GetQueueManagerName()
+		}
+		return "", err
 	}
-	// TODO: What if the specified env variable is an invalid name?
-	return name, nil
+	return strings.TrimSpace(string(buf)), nil
 }
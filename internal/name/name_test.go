@@ -16,30 +16,108 @@ limitations under the License.
 package name
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
-var sanitizeTests = []struct {
+var fromHostnameTests = []struct {
 	in  string
 	out string
 }{
 	{"foo", "foo"},
-	{"foo-0", "foo0"},
-	{"foo-", "foo"},
-	{"-foo", "foo"},
-	{"foo_0", "foo_0"},
+	{"foo-0", "foo_0"},
+	{"my-qm", "my_qm"},
+	{"myqm", "myqm"},
+	{"my.qm", "my.qm"},
+	{"7f3a2b1c9d0e", "7f3a2b1c9d0e"},
+	{"qm@1", "qm_1_995bdff1"},
+	{"_foo", "QM_foo_583da889"},
+	{"", "QM_e3b0c442"},
+	{strings.Repeat("a", 48), strings.Repeat("a", 48)},
+	{strings.Repeat("a", 60), strings.Repeat("a", 39) + "_11ee3912"},
+	{"my-very-long-statefulset-name-for-queue-managers-0", "my_very_long_statefulset_name_for_queue_0f440a28"},
 }
 
-func TestSanitizeQueueManagerName(t *testing.T) {
-	for _, table := range sanitizeTests {
-		s := sanitizeQueueManagerName(table.in)
+func TestFromHostname(t *testing.T) {
+	for _, table := range fromHostnameTests {
+		s := FromHostname(table.in)
 		if s != table.out {
-			t.Errorf("sanitizeQueueManagerName(%v) - expected %v, got %v", table.in, table.out, s)
+			t.Errorf("FromHostname(%v) - expected %v, got %v", table.in, table.out, s)
+		}
+		if err := Validate(s); err != nil {
+			t.Errorf("FromHostname(%v) - expected a valid name, got %v", table.in, err)
+		}
+	}
+}
+
+var legacyFromHostnameTests = []struct {
+	in  string
+	out string
+}{
+	{"mq-0", "mq0"},
+	{"qm1", "qm1"},
+	{"my-qm.example", "myqm.example"},
+}
+
+func TestLegacyFromHostname(t *testing.T) {
+	for _, table := range legacyFromHostnameTests {
+		s := LegacyFromHostname(table.in)
+		if s != table.out {
+			t.Errorf("LegacyFromHostname(%v) - expected %v, got %v", table.in, table.out, s)
 		}
 	}
 }
 
+var validateTests = []struct {
+	in   string
+	kind ErrorKind
+	ok   bool
+}{
+	{"QM1", 0, true},
+	{"qm.a_b%c/d", 0, true},
+	{"1QM", 0, true},
+	{strings.Repeat("Q", 48), 0, true},
+	{"", EmptyName, false},
+	{strings.Repeat("Q", 49), NameTooLong, false},
+	{"my-qm", InvalidCharacter, false},
+	{"QM 1", InvalidCharacter, false},
+	{"QMé", InvalidCharacter, false},
+	{".QM", InvalidFirstCharacter, false},
+	{"_QM", InvalidFirstCharacter, false},
+}
+
+func TestValidate(t *testing.T) {
+	for _, table := range validateTests {
+		err := Validate(table.in)
+		if table.ok {
+			if err != nil {
+				t.Errorf("Validate(%v) - unexpected error: %v", table.in, err)
+			}
+			continue
+		}
+		e, ok := err.(*InvalidNameError)
+		if !ok {
+			t.Errorf("Validate(%v) - expected *InvalidNameError, got %v", table.in, err)
+			continue
+		}
+		if e.Kind != table.kind {
+			t.Errorf("Validate(%v) - expected kind %v, got %v", table.in, table.kind, e.Kind)
+		}
+	}
+}
+
+func TestGetQueueManagerNameInvalid(t *testing.T) {
+	os.Setenv("MQ_QMGR_NAME", "my-qm")
+	defer os.Unsetenv("MQ_QMGR_NAME")
+	_, err := GetQueueManagerName()
+	if _, ok := err.(*InvalidNameError); !ok {
+		t.Errorf("GetQueueManagerName() - expected *InvalidNameError, got %v", err)
+	}
+}
+
 func TestGetQueueManagerNameFromEnv(t *testing.T) {
 	const data string = "foo"
 	err := os.Setenv("MQ_QMGR_NAME", data)
@@ -54,3 +132,26 @@ func TestGetQueueManagerNameFromEnv(t *testing.T) {
 		t.Errorf("Expected name=%v, got name=%v", data, n)
 	}
 }
+
+func TestReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "run", "qmgrname")
+	os.Setenv("MQ_QMGR_NAME", "fromenv")
+	defer os.Unsetenv("MQ_QMGR_NAME")
+	n, err := ReadFile(path)
+	if err != nil || n != "fromenv" {
+		t.Errorf("ReadFile() - expected fromenv when no name is recorded, got %v, %v", n, err)
+	}
+	err = WriteFile(path, "adopted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err = ReadFile(path)
+	if err != nil || n != "adopted" {
+		t.Errorf("ReadFile() - expected adopted, got %v, %v", n, err)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package name
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/internal/templates"
+)
+
+// namespaceFile is the file holding the pod's namespace, in the service
+// account volume mounted by Kubernetes
+const namespaceFile string = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// ordinalPattern matches the ordinal at the end of the name of a pod in a
+// StatefulSet, such as "orders-0"
+var ordinalPattern = regexp.MustCompile(`-(\d+)$`)
+
+// templateData is the data available to queue manager name templates, for
+// example {{ .Hostname }} or {{ .Env.APP_NAME }}
+type templateData struct {
+	Hostname      string
+	Env           map[string]string
+	namespaceFile string
+}
+
+// Ordinal returns the ordinal of the pod in a StatefulSet, parsed from the
+// host name, which Kubernetes sets to the pod name
+func (d *templateData) Ordinal() (string, error) {
+	m := ordinalPattern.FindStringSubmatch(d.Hostname)
+	if m == nil {
+		return "", fmt.Errorf("host name %v doesn't end with a StatefulSet ordinal", d.Hostname)
+	}
+	return m[1], nil
+}
+
+// Namespace returns the Kubernetes namespace, from the POD_NAMESPACE
+// environment variable, or from the service account volume
+func (d *templateData) Namespace() (string, error) {
+	if ns := d.Env["POD_NAMESPACE"]; ns != "" {
+		return ns, nil
+	}
+	buf, err := ioutil.ReadFile(d.namespaceFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to find the namespace: set POD_NAMESPACE, or use a service account: %v", err)
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// isTemplate returns true if a queue manager name contains template actions
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// renderName renders a queue manager name template, and checks that the
+// result is a valid name
+func renderName(text string, data *templateData) (string, error) {
+	getenv := func(name string) string {
+		return data.Env[name]
+	}
+	t, err := templates.New("MQ_QMGR_NAME", getenv).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing MQ_QMGR_NAME template: %v", err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("Error rendering MQ_QMGR_NAME template: %v", err)
+	}
+	name := strings.TrimSpace(buf.String())
+	err = Validate(name)
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// newTemplateData returns the template data for the current environment
+func newTemplateData() (*templateData, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return &templateData{Hostname: hostname, Env: templates.Environ(os.Environ()), namespaceFile: namespaceFile}, nil
+}
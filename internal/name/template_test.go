@@ -0,0 +1,94 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package name
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var renderNameTests = []struct {
+	template string
+	hostname string
+	env      map[string]string
+	out      string
+	err      bool
+}{
+	{"QM_ORDERS_{{ .Ordinal }}", "orders-0", nil, "QM_ORDERS_0", false},
+	{"QM_ORDERS_{{ .Ordinal }}", "orders-mq-12", nil, "QM_ORDERS_12", false},
+	{"{{ .Hostname | replace \"-\" \"_\" | upper }}", "orders-1", nil, "ORDERS_1", false},
+	{"{{ .Namespace | upper }}_{{ .Ordinal }}", "orders-2", map[string]string{"POD_NAMESPACE": "prod"}, "PROD_2", false},
+	{"{{ .Namespace }}_{{ .Ordinal }}", "orders-3", nil, "testns_3", false},
+	{"{{ .Env.APP }}_{{ env \"REGION\" | default \"EU\" }}", "h", map[string]string{"APP": "BILLING"}, "BILLING_EU", false},
+	{"{{ required \"APP\" }}.QM", "h", map[string]string{"APP": "APP1"}, "APP1.QM", false},
+	{"QM_{{ .Ordinal }}", "orders", nil, "", true},
+	{"{{ required \"APP\" }}", "h", nil, "", true},
+	{"{{ .Hostname }}", "orders-0", nil, "", true},
+	{"QM_{{ .Ordinal", "orders-0", nil, "", true},
+	{"{{ .Env.MISSING }}", "h", nil, "", true},
+}
+
+func TestRenderName(t *testing.T) {
+	dir, err := ioutil.TempDir("", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	nsFile := filepath.Join(dir, "namespace")
+	err = ioutil.WriteFile(nsFile, []byte("testns\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range renderNameTests {
+		env := table.env
+		if env == nil {
+			env = map[string]string{}
+		}
+		data := &templateData{Hostname: table.hostname, Env: env, namespaceFile: nsFile}
+		s, err := renderName(table.template, data)
+		if table.err {
+			if err == nil {
+				t.Errorf("renderName(%v) with host name %v - expected error, got %v", table.template, table.hostname, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("renderName(%v) with host name %v - unexpected error: %v", table.template, table.hostname, err)
+			continue
+		}
+		if s != table.out {
+			t.Errorf("renderName(%v) with host name %v - expected %v, got %v", table.template, table.hostname, table.out, s)
+		}
+	}
+}
+
+func TestGetQueueManagerNameTemplate(t *testing.T) {
+	os.Setenv("MQ_QMGR_NAME", "QM_{{ env \"TEST_QM_SUFFIX\" }}")
+	os.Setenv("TEST_QM_SUFFIX", "A1")
+	defer os.Unsetenv("MQ_QMGR_NAME")
+	defer os.Unsetenv("TEST_QM_SUFFIX")
+	n, err := GetQueueManagerName()
+	if err != nil || n != "QM_A1" {
+		t.Errorf("GetQueueManagerName() - expected QM_A1, got %v, %v", n, err)
+	}
+	os.Setenv("TEST_QM_SUFFIX", "a-1")
+	_, err = GetQueueManagerName()
+	if _, ok := err.(*InvalidNameError); !ok {
+		t.Errorf("GetQueueManagerName() - expected *InvalidNameError for an invalid rendered name, got %v", err)
+	}
+}
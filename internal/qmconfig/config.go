@@ -0,0 +1,162 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package qmconfig contains code to read a declarative queue manager
+// configuration file, in YAML or JSON, and to convert it into MQSC commands
+package qmconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DefaultFiles are the locations searched for a configuration file, in order
+var DefaultFiles = []string{
+	"/etc/mqm/qmgr.yaml",
+	"/etc/mqm/qmgr.yml",
+	"/etc/mqm/qmgr.json",
+}
+
+// Config is a declarative queue manager configuration
+type Config struct {
+	Queues      []Queue       `yaml:"queues"`
+	Topics      []Topic       `yaml:"topics"`
+	Channels    []Channel     `yaml:"channels"`
+	Listeners   []Listener    `yaml:"listeners"`
+	ChannelAuth []ChannelAuth `yaml:"channelAuth"`
+	Authorities []Authority   `yaml:"authorities"`
+}
+
+// Queue is a local, remote, alias or model queue
+type Queue struct {
+	Name               string            `yaml:"name"`
+	Type               string            `yaml:"type"`
+	Description        string            `yaml:"description"`
+	MaxDepth           *int              `yaml:"maxDepth"`
+	Persistent         *bool             `yaml:"persistent"`
+	Usage              string            `yaml:"usage"`
+	Target             string            `yaml:"target"`
+	RemoteName         string            `yaml:"remoteName"`
+	RemoteQueueManager string            `yaml:"remoteQueueManager"`
+	TransmissionQueue  string            `yaml:"transmissionQueue"`
+	Attributes         map[string]string `yaml:"attributes"`
+}
+
+// Topic is an administrative topic object
+type Topic struct {
+	Name        string            `yaml:"name"`
+	TopicString string            `yaml:"topicString"`
+	Description string            `yaml:"description"`
+	Attributes  map[string]string `yaml:"attributes"`
+}
+
+// Channel is a channel definition
+type Channel struct {
+	Name              string            `yaml:"name"`
+	Type              string            `yaml:"type"`
+	Description       string            `yaml:"description"`
+	ConnectionName    string            `yaml:"connectionName"`
+	TransmissionQueue string            `yaml:"transmissionQueue"`
+	QueueManager      string            `yaml:"queueManager"`
+	CipherSpec        string            `yaml:"cipherSpec"`
+	MCAUser           string            `yaml:"mcaUser"`
+	Attributes        map[string]string `yaml:"attributes"`
+}
+
+// Listener is a TCP listener
+type Listener struct {
+	Name        string            `yaml:"name"`
+	Port        int               `yaml:"port"`
+	IPAddress   string            `yaml:"ipAddress"`
+	Backlog     *int              `yaml:"backlog"`
+	Control     string            `yaml:"control"`
+	Description string            `yaml:"description"`
+	Attributes  map[string]string `yaml:"attributes"`
+}
+
+// ChannelAuth is a channel authentication (CHLAUTH) rule
+type ChannelAuth struct {
+	Channel      string   `yaml:"channel"`
+	Type         string   `yaml:"type"`
+	Description  string   `yaml:"description"`
+	Address      string   `yaml:"address"`
+	AddressList  []string `yaml:"addressList"`
+	UserList     []string `yaml:"userList"`
+	ClientUser   string   `yaml:"clientUser"`
+	SSLPeer      string   `yaml:"sslPeer"`
+	QueueManager string   `yaml:"queueManager"`
+	UserSource   string   `yaml:"userSource"`
+	MCAUser      string   `yaml:"mcaUser"`
+	CheckClient  string   `yaml:"checkClient"`
+	Warn         *bool    `yaml:"warn"`
+}
+
+// Authority is an authority record, granting authorities to a user or group
+type Authority struct {
+	Profile     string   `yaml:"profile"`
+	ObjectType  string   `yaml:"objectType"`
+	Principal   string   `yaml:"principal"`
+	Group       string   `yaml:"group"`
+	Authorities []string `yaml:"authorities"`
+}
+
+// Parse parses and validates a configuration in YAML or JSON format.  If the
+// configuration is invalid, then the error is of type ValidationErrors.
+func Parse(data []byte) (*Config, error) {
+	var doc interface{}
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+	err = validate(doc)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	err = yaml.UnmarshalStrict(data, c)
+	if err != nil {
+		return nil, err
+	}
+	err = c.validate()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load reads, parses and validates a configuration file
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Find returns the first of the specified files which exists, or an empty
+// string if none of them exist
+func Find(paths []string) string {
+	for _, path := range paths {
+		_, err := os.Stat(path)
+		if err == nil {
+			return filepath.Clean(path)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package qmconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+const exampleYAML string = `
+queues:
+  - name: APP.QUEUE
+    maxDepth: 5000
+    persistent: true
+  - name: APP.ALIAS
+    type: alias
+    target: APP.QUEUE
+channels:
+  - name: APP.SVRCONN
+    type: svrconn
+    mcaUser: app
+listeners:
+  - name: LISTENER.TCP
+    port: 1414
+channelAuth:
+  - channel: APP.SVRCONN
+    type: addressmap
+    address: "*"
+    userSource: channel
+authorities:
+  - profile: APP.**
+    objectType: queue
+    group: apps
+    authorities: [get, put, inq]
+`
+
+const exampleJSON string = `{
+  "queues": [{"name": "APP.QUEUE", "maxDepth": 5000, "persistent": true}],
+  "topics": [{"name": "APP.TOPIC", "topicString": "app/events"}]
+}`
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(exampleYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Queues) != 2 || len(c.Channels) != 1 || len(c.Listeners) != 1 || len(c.ChannelAuth) != 1 || len(c.Authorities) != 1 {
+		t.Errorf("Parse() - unexpected configuration %+v", c)
+	}
+	if c.Queues[0].MaxDepth == nil || *c.Queues[0].MaxDepth != 5000 {
+		t.Errorf("Parse() - expected maxDepth 5000, got %v", c.Queues[0].MaxDepth)
+	}
+	c, err = Parse([]byte(exampleJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Queues) != 1 || len(c.Topics) != 1 || c.Topics[0].TopicString != "app/events" {
+		t.Errorf("Parse() - unexpected configuration %+v", c)
+	}
+	c, err = Parse([]byte(""))
+	if err != nil {
+		t.Errorf("Parse() - unexpected error for empty configuration: %v", err)
+	}
+}
+
+var parseErrorTests = []struct {
+	in    string
+	paths []string
+}{
+	{"queues:\n  - maxDepth: 10\n", []string{"queues[0].name"}},
+	{"queues:\n  - name: A\n    maxDepth: lots\n", []string{"queues[0].maxDepth"}},
+	{"queues:\n  - name: A\n  - name: B\n    colour: red\n", []string{"queues[1].colour"}},
+	{"queues:\n  - name: A\n    type: fifo\n", []string{"queues[0].type"}},
+	{"queues:\n  - name: A\n  - name: A\n    type: alias\n    target: B\n", []string{"queues[1].name"}},
+	{"queues:\n  - name: A\n    type: alias\n", []string{"queues[0].target"}},
+	{"queues: APP.QUEUE\n", []string{"queues"}},
+	{"qeueus:\n  - name: A\n", []string{"qeueus"}},
+	{"channels:\n  - name: TO.QM2\n    type: sdr\n", []string{"channels[0].transmissionQueue", "channels[0].connectionName"}},
+	{"channels:\n  - name: A.VERY.LONG.CHANNEL.NAME\n    type: svrconn\n", []string{"channels[0].name"}},
+	{"listeners:\n  - name: L\n    port: 99999\n", []string{"listeners[0].port"}},
+	{"queues:\n  - name: bad name!\n", []string{"queues[0].name"}},
+	{"queues:\n  - name: A\n    type: alias\n    target: Q(1)\n", []string{"queues[0].target"}},
+	{"topics:\n  - name: T 1\n    topicString: a/b\n", []string{"topics[0].name"}},
+	{"channels:\n  - name: APP*SVRCONN\n    type: svrconn\n", []string{"channels[0].name"}},
+	{"listeners:\n  - name: L(1)\n    port: 1414\n", []string{"listeners[0].name"}},
+	{"channelAuth:\n  - channel: APP.SVRCONN\n    type: blockuser\n", []string{"channelAuth[0].userList"}},
+	{"authorities:\n  - profile: A\n    objectType: queue\n    authorities: [get, eat]\n", []string{"authorities[0].authorities[1]"}},
+	{"authorities:\n  - profile: A\n    objectType: queue\n    authorities: [get]\n", []string{"authorities[0]"}},
+	{"queues:\n  - name: A\n    attributes:\n      MAXMSGL: [1, 2]\n", []string{"queues[0].attributes.MAXMSGL"}},
+	{"queues:\n  - name: A\n    attributes:\n      custom: X\n", []string{"queues[0].attributes.custom"}},
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, table := range parseErrorTests {
+		_, err := Parse([]byte(table.in))
+		errs, ok := err.(ValidationErrors)
+		if !ok {
+			t.Errorf("Parse(%q) - expected validation errors, got %v", table.in, err)
+			continue
+		}
+		paths := make([]string, len(errs))
+		for i, e := range errs {
+			paths[i] = e.Path
+		}
+		if !reflect.DeepEqual(paths, table.paths) {
+			t.Errorf("Parse(%q) - expected errors at %v, got %v", table.in, table.paths, errs)
+		}
+	}
+}
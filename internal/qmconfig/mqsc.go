@@ -0,0 +1,341 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package qmconfig
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Label is the value of the CUSTOM attribute set on every object defined
+// from the configuration, so that they can be found again when pruning
+const Label string = "RUNMQSERVER(MANAGED)"
+
+// quote returns a string as a quoted MQSC value, with any quotes escaped
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// unquote reverses quote.  Values which aren't quoted are returned unchanged.
+func unquote(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1)
+	}
+	return s
+}
+
+// quoteList returns a list of strings as a comma-separated list of quoted
+// MQSC values
+func quoteList(l []string) string {
+	q := make([]string, len(l))
+	for i, s := range l {
+		q[i] = quote(s)
+	}
+	return strings.Join(q, ",")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "YES"
+	}
+	return "NO"
+}
+
+// attribute is a single MQSC attribute, such as "MAXDEPTH(5000)"
+type attribute struct {
+	name  string
+	value string
+	// key is true for attributes which identify the type of object, and so
+	// must be included in ALTER commands
+	key bool
+}
+
+func (a attribute) String() string {
+	return fmt.Sprintf("%v(%v)", a.name, a.value)
+}
+
+// command builds a single MQSC command
+type command struct {
+	verb   string
+	object string
+	attrs  []attribute
+}
+
+func newCommand(verb string, object string) *command {
+	return &command{verb: verb, object: object}
+}
+
+// add adds an attribute with a value, such as "MAXDEPTH(5000)"
+func (c *command) add(name string, value string) *command {
+	c.attrs = append(c.attrs, attribute{name: name, value: value})
+	return c
+}
+
+// addKey adds an attribute which identifies the type of object
+func (c *command) addKey(name string, value string) *command {
+	c.attrs = append(c.attrs, attribute{name: name, value: value, key: true})
+	return c
+}
+
+// addQuoted adds an attribute with a quoted value, unless the value is empty
+func (c *command) addQuoted(name string, value string) *command {
+	if value != "" {
+		c.add(name, quote(value))
+	}
+	return c
+}
+
+// addKeyword adds an attribute with a keyword value in upper case, unless
+// the value is empty
+func (c *command) addKeyword(name string, value string) *command {
+	if value != "" {
+		c.add(name, strings.ToUpper(value))
+	}
+	return c
+}
+
+// addAttributes adds extra attributes, sorted by name.  The values are used
+// as they are, so must be quoted by the user if necessary.
+func (c *command) addAttributes(attrs map[string]string) *command {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.add(strings.ToUpper(name), attrs[name])
+	}
+	return c
+}
+
+func (c *command) String() string {
+	var buf bytes.Buffer
+	buf.WriteString(c.verb)
+	buf.WriteString(" ")
+	buf.WriteString(c.object)
+	for _, a := range c.attrs {
+		buf.WriteString(" ")
+		buf.WriteString(a.String())
+	}
+	return buf.String()
+}
+
+// definition is a named object, which is created with DEFINE
+type definition struct {
+	// path is the location of the object in the configuration
+	path string
+	// kind is the generic object type used with DISPLAY, such as "QUEUE"
+	kind string
+	// keyword is the object type used with DEFINE, such as "QLOCAL"
+	keyword string
+	name    string
+	// typeAttr and typeValue identify the specific object type in DISPLAY
+	// output, such as "QTYPE(QLOCAL)"
+	typeAttr  string
+	typeValue string
+	*command
+}
+
+func newDefinition(path string, kind string, keyword string, name string) *definition {
+	return &definition{
+		path:    path,
+		kind:    kind,
+		keyword: keyword,
+		name:    name,
+		command: newCommand("DEFINE", fmt.Sprintf("%v(%v)", keyword, quote(name))),
+	}
+}
+
+// MQSC returns the command to define the object, replacing any existing one
+func (d *definition) MQSC() string {
+	return d.String() + " REPLACE"
+}
+
+var queueTypes = map[string]string{
+	"":       "QLOCAL",
+	"local":  "QLOCAL",
+	"remote": "QREMOTE",
+	"alias":  "QALIAS",
+	"model":  "QMODEL",
+}
+
+func (q *Queue) definition(path string) *definition {
+	keyword := queueTypes[strings.ToLower(q.Type)]
+	d := newDefinition(path, "QUEUE", keyword, q.Name)
+	d.typeAttr, d.typeValue = "QTYPE", keyword
+	d.addQuoted("DESCR", q.Description)
+	if q.MaxDepth != nil {
+		d.add("MAXDEPTH", fmt.Sprint(*q.MaxDepth))
+	}
+	if q.Persistent != nil {
+		d.add("DEFPSIST", yesNo(*q.Persistent))
+	}
+	d.addKeyword("USAGE", q.Usage)
+	d.addQuoted("TARGET", q.Target)
+	d.addQuoted("RNAME", q.RemoteName)
+	d.addQuoted("RQMNAME", q.RemoteQueueManager)
+	d.addQuoted("XMITQ", q.TransmissionQueue)
+	d.addAttributes(q.Attributes)
+	d.addQuoted("CUSTOM", Label)
+	return d
+}
+
+// MQSC returns the command to define the queue
+func (q *Queue) MQSC() string {
+	return q.definition("").MQSC()
+}
+
+func (t *Topic) definition(path string) *definition {
+	d := newDefinition(path, "TOPIC", "TOPIC", t.Name)
+	// The topic string can't be altered, so is treated as part of the type
+	d.typeAttr, d.typeValue = "TOPICSTR", t.TopicString
+	d.addQuoted("TOPICSTR", t.TopicString)
+	d.addQuoted("DESCR", t.Description)
+	d.addAttributes(t.Attributes)
+	d.addQuoted("CUSTOM", Label)
+	return d
+}
+
+// MQSC returns the command to define the topic
+func (t *Topic) MQSC() string {
+	return t.definition("").MQSC()
+}
+
+func (ch *Channel) definition(path string) *definition {
+	d := newDefinition(path, "CHANNEL", "CHANNEL", ch.Name)
+	d.typeAttr, d.typeValue = "CHLTYPE", strings.ToUpper(ch.Type)
+	d.addKey("CHLTYPE", strings.ToUpper(ch.Type))
+	d.add("TRPTYPE", "TCP")
+	d.addQuoted("DESCR", ch.Description)
+	d.addQuoted("CONNAME", ch.ConnectionName)
+	d.addQuoted("XMITQ", ch.TransmissionQueue)
+	d.addQuoted("QMNAME", ch.QueueManager)
+	d.addQuoted("SSLCIPH", ch.CipherSpec)
+	d.addQuoted("MCAUSER", ch.MCAUser)
+	d.addAttributes(ch.Attributes)
+	d.addQuoted("CUSTOM", Label)
+	return d
+}
+
+// MQSC returns the command to define the channel
+func (ch *Channel) MQSC() string {
+	return ch.definition("").MQSC()
+}
+
+func (l *Listener) definition(path string) *definition {
+	d := newDefinition(path, "LISTENER", "LISTENER", l.Name)
+	d.addKey("TRPTYPE", "TCP")
+	d.add("PORT", fmt.Sprint(l.Port))
+	d.addQuoted("IPADDR", l.IPAddress)
+	if l.Backlog != nil {
+		d.add("BACKLOG", fmt.Sprint(*l.Backlog))
+	}
+	control := l.Control
+	if control == "" {
+		control = "qmgr"
+	}
+	d.addKeyword("CONTROL", control)
+	d.addQuoted("DESCR", l.Description)
+	d.addAttributes(l.Attributes)
+	d.addQuoted("CUSTOM", Label)
+	return d
+}
+
+// MQSC returns the command to define the listener
+func (l *Listener) MQSC() string {
+	return l.definition("").MQSC()
+}
+
+// MQSC returns the command to set the channel authentication rule
+func (r *ChannelAuth) MQSC() string {
+	c := newCommand("SET", fmt.Sprintf("CHLAUTH(%v)", quote(r.Channel)))
+	c.addKeyword("TYPE", r.Type)
+	c.addQuoted("DESCR", r.Description)
+	c.addQuoted("ADDRESS", r.Address)
+	if len(r.AddressList) > 0 {
+		c.add("ADDRLIST", quoteList(r.AddressList))
+	}
+	if len(r.UserList) > 0 {
+		c.add("USERLIST", quoteList(r.UserList))
+	}
+	c.addQuoted("CLNTUSER", r.ClientUser)
+	c.addQuoted("SSLPEER", r.SSLPeer)
+	c.addQuoted("QMNAME", r.QueueManager)
+	c.addKeyword("USERSRC", r.UserSource)
+	c.addQuoted("MCAUSER", r.MCAUser)
+	c.addKeyword("CHCKCLNT", r.CheckClient)
+	if r.Warn != nil {
+		c.add("WARN", yesNo(*r.Warn))
+	}
+	return c.add("ACTION", "REPLACE").String()
+}
+
+// MQSC returns the command to set the authority record
+func (a *Authority) MQSC() string {
+	c := newCommand("SET", "AUTHREC")
+	c.addQuoted("PROFILE", a.Profile)
+	c.addKeyword("OBJTYPE", a.ObjectType)
+	c.addQuoted("PRINCIPAL", a.Principal)
+	c.addQuoted("GROUP", a.Group)
+	return c.add("AUTHADD", strings.ToUpper(strings.Join(a.Authorities, ","))).String()
+}
+
+// definitions returns the named objects in the configuration
+func (c *Config) definitions() []*definition {
+	defs := make([]*definition, 0)
+	for i := range c.Queues {
+		defs = append(defs, c.Queues[i].definition(fmt.Sprintf("queues[%v]", i)))
+	}
+	for i := range c.Topics {
+		defs = append(defs, c.Topics[i].definition(fmt.Sprintf("topics[%v]", i)))
+	}
+	for i := range c.Channels {
+		defs = append(defs, c.Channels[i].definition(fmt.Sprintf("channels[%v]", i)))
+	}
+	for i := range c.Listeners {
+		defs = append(defs, c.Listeners[i].definition(fmt.Sprintf("listeners[%v]", i)))
+	}
+	return defs
+}
+
+// rules returns the MQSC commands for the channel authentication rules and
+// authority records, which are set rather than defined, and so can be run
+// again without resetting any other attributes
+func (c *Config) rules() []Change {
+	changes := make([]Change, 0)
+	for i := range c.ChannelAuth {
+		changes = append(changes, Change{Path: fmt.Sprintf("channelAuth[%v]", i), Action: ActionSet, MQSC: c.ChannelAuth[i].MQSC()})
+	}
+	for i := range c.Authorities {
+		changes = append(changes, Change{Path: fmt.Sprintf("authorities[%v]", i), Action: ActionSet, MQSC: c.Authorities[i].MQSC()})
+	}
+	return changes
+}
+
+// MQSC returns the MQSC commands for the whole configuration.  Each command
+// is preceded by a comment giving the path of the object in the configuration.
+func (c *Config) MQSC() string {
+	var buf bytes.Buffer
+	for _, d := range c.definitions() {
+		fmt.Fprintf(&buf, "* %v\n%v\n", d.path, d.MQSC())
+	}
+	for _, r := range c.rules() {
+		fmt.Fprintf(&buf, "* %v\n%v\n", r.Path, r.MQSC)
+	}
+	return buf.String()
+}
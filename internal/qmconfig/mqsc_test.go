@@ -0,0 +1,68 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package qmconfig
+
+import (
+	"testing"
+)
+
+var mqscTests = []struct {
+	in  string
+	out string
+}{
+	{
+		"queues:\n  - name: APP.QUEUE\n    description: Bob's queue\n    maxDepth: 5000\n    persistent: false\n    attributes:\n      maxmsgl: 1048576\n",
+		"* queues[0]\nDEFINE QLOCAL('APP.QUEUE') DESCR('Bob''s queue') MAXDEPTH(5000) DEFPSIST(NO) MAXMSGL(1048576) CUSTOM('RUNMQSERVER(MANAGED)') REPLACE\n",
+	},
+	{
+		"queues:\n  - name: TO.QM2\n    type: remote\n    remoteName: IN\n    remoteQueueManager: QM2\n    transmissionQueue: QM2\n",
+		"* queues[0]\nDEFINE QREMOTE('TO.QM2') RNAME('IN') RQMNAME('QM2') XMITQ('QM2') CUSTOM('RUNMQSERVER(MANAGED)') REPLACE\n",
+	},
+	{
+		"topics:\n  - name: APP.TOPIC\n    topicString: app/events\n",
+		"* topics[0]\nDEFINE TOPIC('APP.TOPIC') TOPICSTR('app/events') CUSTOM('RUNMQSERVER(MANAGED)') REPLACE\n",
+	},
+	{
+		"channels:\n  - name: TO.QM2\n    type: sdr\n    connectionName: qm2(1414)\n    transmissionQueue: QM2\n",
+		"* channels[0]\nDEFINE CHANNEL('TO.QM2') CHLTYPE(SDR) TRPTYPE(TCP) CONNAME('qm2(1414)') XMITQ('QM2') CUSTOM('RUNMQSERVER(MANAGED)') REPLACE\n",
+	},
+	{
+		"listeners:\n  - name: LISTENER.TCP\n    port: 1415\n",
+		"* listeners[0]\nDEFINE LISTENER('LISTENER.TCP') TRPTYPE(TCP) PORT(1415) CONTROL(QMGR) CUSTOM('RUNMQSERVER(MANAGED)') REPLACE\n",
+	},
+	{
+		"channelAuth:\n  - channel: APP.SVRCONN\n    type: blockuser\n    userList: [nobody, '*MQADMIN']\n",
+		"* channelAuth[0]\nSET CHLAUTH('APP.SVRCONN') TYPE(BLOCKUSER) USERLIST('nobody','*MQADMIN') ACTION(REPLACE)\n",
+	},
+	{
+		"authorities:\n  - objectType: qmgr\n    group: apps\n    authorities: [connect, inq]\n",
+		"* authorities[0]\nSET AUTHREC OBJTYPE(QMGR) GROUP('apps') AUTHADD(CONNECT,INQ)\n",
+	},
+}
+
+func TestMQSC(t *testing.T) {
+	for _, table := range mqscTests {
+		c, err := Parse([]byte(table.in))
+		if err != nil {
+			t.Errorf("Parse(%q) - unexpected error: %v", table.in, err)
+			continue
+		}
+		out := c.MQSC()
+		if out != table.out {
+			t.Errorf("MQSC(%q) - expected %q, got %q", table.in, table.out, out)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package qmconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Actions for a planned change
+const (
+	ActionDefine   string = "define"
+	ActionAlter    string = "alter"
+	ActionDelete   string = "delete"
+	ActionSet      string = "set"
+	ActionConflict string = "conflict"
+)
+
+// Change is a planned change to the queue manager
+type Change struct {
+	// Path is the location in the configuration which caused the change,
+	// which is empty for objects being deleted
+	Path   string
+	Action string
+	// Object is the object being changed, such as "QLOCAL('APP.QUEUE')"
+	Object string
+	// Diff describes each attribute being changed, such as
+	// "MAXDEPTH: 5000 -> 10000"
+	Diff []string
+	// MQSC is the command to make the change, which is empty for a conflict
+	MQSC string
+}
+
+func (c Change) String() string {
+	s := c.Action
+	if c.Object != "" {
+		s += " " + c.Object
+	} else {
+		s += " " + c.MQSC
+	}
+	if c.Path != "" {
+		s += " (" + c.Path + ")"
+	}
+	if len(c.Diff) > 0 {
+		s += ": " + strings.Join(c.Diff, ", ")
+	}
+	return s
+}
+
+// DisplayFunc runs an MQSC DISPLAY command, and returns the attributes of
+// each object displayed, keyed by attribute name
+type DisplayFunc func(mqsc string) ([]map[string]string, error)
+
+// kinds are the object types which can be reconciled, in the order in which
+// they are defined
+var kinds = []string{"QUEUE", "TOPIC", "CHANNEL", "LISTENER"}
+
+// equalValue returns true if the value of an attribute shown by DISPLAY is
+// the same as a value used in a DEFINE command
+func equalValue(current string, desired string) bool {
+	current = strings.TrimSpace(current)
+	if strings.HasPrefix(desired, "'") {
+		return current == strings.TrimSpace(unquote(desired))
+	}
+	return strings.EqualFold(current, desired)
+}
+
+// plan compares a definition with the current attributes of the object
+func (d *definition) plan(current map[string]string) (Change, bool) {
+	object := fmt.Sprintf("%v(%v)", d.keyword, quote(d.name))
+	if current == nil {
+		return Change{Path: d.path, Action: ActionDefine, Object: object, MQSC: d.MQSC()}, true
+	}
+	if d.typeAttr != "" && !equalValue(current[d.typeAttr], quote(d.typeValue)) {
+		diff := fmt.Sprintf("%v: %v -> %v", d.typeAttr, current[d.typeAttr], d.typeValue)
+		return Change{Path: d.path, Action: ActionConflict, Object: object, Diff: []string{diff}}, true
+	}
+	alter := newCommand("ALTER", object)
+	diff := make([]string, 0)
+	for _, a := range d.attrs {
+		if a.key {
+			alter.attrs = append(alter.attrs, a)
+			continue
+		}
+		value, ok := current[a.name]
+		if !ok || !equalValue(value, a.value) {
+			alter.attrs = append(alter.attrs, a)
+			diff = append(diff, fmt.Sprintf("%v: %v -> %v", a.name, strings.TrimSpace(value), unquote(a.value)))
+		}
+	}
+	if len(diff) == 0 {
+		return Change{}, false
+	}
+	return Change{Path: d.path, Action: ActionAlter, Object: object, Diff: diff, MQSC: alter.String()}, true
+}
+
+// deleteCommand returns the command to delete an object which is no longer
+// in the configuration
+func deleteCommand(kind string, current map[string]string) (string, string) {
+	keyword := kind
+	if kind == "QUEUE" {
+		keyword = current["QTYPE"]
+	}
+	object := fmt.Sprintf("%v(%v)", keyword, quote(current[kind]))
+	return object, "DELETE " + object
+}
+
+// Plan compares the configuration with the objects which currently exist,
+// and returns the changes needed to make them match.  Attributes which
+// aren't in the configuration are left unchanged.  If prune is true, then
+// objects labelled as defined from a configuration, which are no longer in
+// the configuration, are deleted.
+func (c *Config) Plan(display DisplayFunc, prune bool) ([]Change, error) {
+	defs := make(map[string][]*definition)
+	for _, d := range c.definitions() {
+		defs[d.kind] = append(defs[d.kind], d)
+	}
+	changes := make([]Change, 0)
+	deletes := make([]Change, 0)
+	for _, kind := range kinds {
+		if len(defs[kind]) == 0 && !prune {
+			continue
+		}
+		objects, err := display(fmt.Sprintf("DISPLAY %v(*) ALL", kind))
+		if err != nil {
+			return nil, err
+		}
+		current := make(map[string]map[string]string)
+		for _, o := range objects {
+			current[o[kind]] = o
+		}
+		declared := make(map[string]bool)
+		for _, d := range defs[kind] {
+			declared[d.name] = true
+			change, ok := d.plan(current[d.name])
+			if ok {
+				changes = append(changes, change)
+			}
+		}
+		if !prune {
+			continue
+		}
+		names := make([]string, 0)
+		for name, o := range current {
+			if !declared[name] && strings.Contains(o["CUSTOM"], Label) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			object, mqsc := deleteCommand(kind, current[name])
+			deletes = append(deletes, Change{Action: ActionDelete, Object: object, MQSC: mqsc})
+		}
+	}
+	changes = append(changes, c.rules()...)
+	// Delete objects last, so that nothing which is still defined refers to them
+	return append(changes, deletes...), nil
+}
@@ -0,0 +1,123 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package qmconfig
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const reconcileYAML string = `
+queues:
+  - name: APP.NEW
+  - name: APP.SAME
+    maxDepth: 5000
+    description: Same
+  - name: APP.CHANGED
+    maxDepth: 10000
+  - name: APP.ALIAS
+    type: alias
+    target: APP.SAME
+topics:
+  - name: APP.TOPIC
+    topicString: app/new
+channels:
+  - name: APP.SVRCONN
+    type: svrconn
+    mcaUser: app
+authorities:
+  - profile: APP.**
+    objectType: queue
+    group: apps
+    authorities: [get]
+`
+
+// currentObjects are the objects which exist on the queue manager, keyed by
+// the DISPLAY command
+var currentObjects = map[string][]map[string]string{
+	"DISPLAY QUEUE(*) ALL": {
+		{"QUEUE": "SYSTEM.DEFAULT.LOCAL.QUEUE", "QTYPE": "QLOCAL", "MAXDEPTH": "5000", "CUSTOM": " "},
+		{"QUEUE": "APP.SAME", "QTYPE": "QLOCAL", "MAXDEPTH": "5000", "DESCR": "Same", "CUSTOM": "RUNMQSERVER(MANAGED)"},
+		{"QUEUE": "APP.CHANGED", "QTYPE": "QLOCAL", "MAXDEPTH": "5000", "DESCR": " ", "CUSTOM": " "},
+		{"QUEUE": "APP.ALIAS", "QTYPE": "QLOCAL", "MAXDEPTH": "5000", "CUSTOM": "RUNMQSERVER(MANAGED)"},
+		{"QUEUE": "APP.OLD", "QTYPE": "QREMOTE", "CUSTOM": "RUNMQSERVER(MANAGED)"},
+	},
+	"DISPLAY TOPIC(*) ALL": {
+		{"TOPIC": "APP.TOPIC", "TOPICSTR": "app/old", "CUSTOM": "RUNMQSERVER(MANAGED)"},
+	},
+	"DISPLAY CHANNEL(*) ALL": {
+		{"CHANNEL": "APP.SVRCONN", "CHLTYPE": "SVRCONN", "TRPTYPE": "TCP", "MCAUSER": "app", "CUSTOM": "RUNMQSERVER(MANAGED)"},
+		{"CHANNEL": "OLD.SVRCONN", "CHLTYPE": "SVRCONN", "TRPTYPE": "TCP", "CUSTOM": "RUNMQSERVER(MANAGED)"},
+	},
+	"DISPLAY LISTENER(*) ALL": {
+		{"LISTENER": "SYSTEM.DEFAULT.LISTENER.TCP", "TRPTYPE": "TCP", "CUSTOM": " "},
+	},
+}
+
+func display(mqsc string) ([]map[string]string, error) {
+	objects, ok := currentObjects[mqsc]
+	if !ok {
+		return nil, errors.New("Unexpected command: " + mqsc)
+	}
+	return objects, nil
+}
+
+func TestPlan(t *testing.T) {
+	c, err := Parse([]byte(reconcileYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, prune := range []bool{false, true} {
+		changes, err := c.Plan(display, prune)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mqsc := make([]string, len(changes))
+		for i, change := range changes {
+			mqsc[i] = change.Action + ": " + change.MQSC
+		}
+		expected := []string{
+			"define: DEFINE QLOCAL('APP.NEW') CUSTOM('RUNMQSERVER(MANAGED)') REPLACE",
+			"alter: ALTER QLOCAL('APP.CHANGED') MAXDEPTH(10000) CUSTOM('RUNMQSERVER(MANAGED)')",
+			"conflict: ",
+			"conflict: ",
+			"set: SET AUTHREC PROFILE('APP.**') OBJTYPE(QUEUE) GROUP('apps') AUTHADD(GET)",
+		}
+		if prune {
+			expected = append(expected,
+				"delete: DELETE QREMOTE('APP.OLD')",
+				"delete: DELETE CHANNEL('OLD.SVRCONN')")
+		}
+		if !reflect.DeepEqual(mqsc, expected) {
+			t.Errorf("Plan(%v) - expected %v, got %v", prune, expected, mqsc)
+		}
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	c := Change{
+		Path:   "queues[1]",
+		Action: ActionAlter,
+		Object: "QLOCAL('APP.CHANGED')",
+		Diff:   []string{"MAXDEPTH: 5000 -> 10000"},
+		MQSC:   "ALTER QLOCAL('APP.CHANGED') MAXDEPTH(10000)",
+	}
+	expected := "alter QLOCAL('APP.CHANGED') (queues[1]): MAXDEPTH: 5000 -> 10000"
+	if c.String() != expected {
+		t.Errorf("String() - expected %v, got %v", expected, c.String())
+	}
+}
@@ -0,0 +1,438 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package qmconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ibm-messaging/mq-container/internal/mqsc"
+)
+
+// ValidationError is a problem with the configuration, at the specified
+// path, such as "queues[2].maxDepth"
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors holds all the problems found with a configuration
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindBool
+	kindStringList
+	kindStringMap
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindInt:
+		return "an integer"
+	case kindBool:
+		return "a boolean"
+	case kindStringList:
+		return "a list of strings"
+	case kindStringMap:
+		return "a map of attributes"
+	}
+	return "a string"
+}
+
+// field describes a field in the schema
+type field struct {
+	kind      kind
+	required  bool
+	maxLength int
+	// objectName is true if the field is the name of an MQ object
+	objectName bool
+	// values holds the allowed values for the field, in lower case
+	values []string
+	min    int
+	max    int
+}
+
+type schema map[string]field
+
+var (
+	queueSchema = schema{
+		"name":               {kind: kindString, required: true, maxLength: 48, objectName: true},
+		"type":               {kind: kindString, values: []string{"local", "remote", "alias", "model"}},
+		"description":        {kind: kindString, maxLength: 64},
+		"maxDepth":           {kind: kindInt, min: 0, max: 999999999},
+		"persistent":         {kind: kindBool},
+		"usage":              {kind: kindString, values: []string{"normal", "xmitq"}},
+		"target":             {kind: kindString, maxLength: 48, objectName: true},
+		"remoteName":         {kind: kindString, maxLength: 48},
+		"remoteQueueManager": {kind: kindString, maxLength: 48},
+		"transmissionQueue":  {kind: kindString, maxLength: 48, objectName: true},
+		"attributes":         {kind: kindStringMap},
+	}
+	topicSchema = schema{
+		"name":        {kind: kindString, required: true, maxLength: 48, objectName: true},
+		"topicString": {kind: kindString, required: true},
+		"description": {kind: kindString, maxLength: 64},
+		"attributes":  {kind: kindStringMap},
+	}
+	channelSchema = schema{
+		"name":              {kind: kindString, required: true, maxLength: 20, objectName: true},
+		"type":              {kind: kindString, required: true, values: []string{"svrconn", "sdr", "rcvr", "svr", "rqstr", "clntconn", "clussdr", "clusrcvr"}},
+		"description":       {kind: kindString, maxLength: 64},
+		"connectionName":    {kind: kindString, maxLength: 264},
+		"transmissionQueue": {kind: kindString, maxLength: 48, objectName: true},
+		"queueManager":      {kind: kindString, maxLength: 48},
+		"cipherSpec":        {kind: kindString, maxLength: 32},
+		"mcaUser":           {kind: kindString, maxLength: 12},
+		"attributes":        {kind: kindStringMap},
+	}
+	listenerSchema = schema{
+		"name":        {kind: kindString, required: true, maxLength: 48, objectName: true},
+		"port":        {kind: kindInt, required: true, min: 1, max: 65535},
+		"ipAddress":   {kind: kindString, maxLength: 48},
+		"backlog":     {kind: kindInt, min: 0, max: 999999999},
+		"control":     {kind: kindString, values: []string{"qmgr", "startonly", "manual"}},
+		"description": {kind: kindString, maxLength: 64},
+		"attributes":  {kind: kindStringMap},
+	}
+	channelAuthSchema = schema{
+		"channel":      {kind: kindString, required: true, maxLength: 20},
+		"type":         {kind: kindString, required: true, values: []string{"blockuser", "blockaddr", "addressmap", "usermap", "sslpeermap", "qmgrmap"}},
+		"description":  {kind: kindString, maxLength: 64},
+		"address":      {kind: kindString},
+		"addressList":  {kind: kindStringList},
+		"userList":     {kind: kindStringList},
+		"clientUser":   {kind: kindString},
+		"sslPeer":      {kind: kindString},
+		"queueManager": {kind: kindString, maxLength: 48},
+		"userSource":   {kind: kindString, values: []string{"map", "noaccess", "channel"}},
+		"mcaUser":      {kind: kindString, maxLength: 12},
+		"checkClient":  {kind: kindString, values: []string{"asqmgr", "required", "reqdadm"}},
+		"warn":         {kind: kindBool},
+	}
+	authoritySchema = schema{
+		"profile":     {kind: kindString},
+		"objectType":  {kind: kindString, required: true, values: []string{"queue", "topic", "qmgr", "channel", "clntconn", "listener", "namelist", "process", "service", "authinfo", "comminfo", "rqmname"}},
+		"principal":   {kind: kindString},
+		"group":       {kind: kindString},
+		"authorities": {kind: kindStringList, required: true, values: authorityValues},
+	}
+)
+
+// authorityValues are the authorities which can be granted by SET AUTHREC
+var authorityValues = []string{
+	"all", "alladm", "allmqi", "none",
+	"altusr", "browse", "chg", "clr", "connect", "crt", "ctrl", "ctrlx", "dlt", "dsp",
+	"get", "inq", "passall", "passid", "pub", "put", "resume", "set", "setall", "setid", "sub", "system",
+}
+
+// sections holds the schema for the items in each top-level list
+var sections = map[string]schema{
+	"queues":      queueSchema,
+	"topics":      topicSchema,
+	"channels":    channelSchema,
+	"listeners":   listenerSchema,
+	"channelAuth": channelAuthSchema,
+	"authorities": authoritySchema,
+}
+
+// sortedKeys returns the keys of a YAML map as sorted strings, along with a
+// map from each string to the original key
+func sortedKeys(m map[interface{}]interface{}) ([]string, map[string]interface{}) {
+	keys := make([]string, 0, len(m))
+	orig := make(map[string]interface{}, len(m))
+	for k := range m {
+		s := fmt.Sprint(k)
+		keys = append(keys, s)
+		orig[s] = k
+	}
+	sort.Strings(keys)
+	return keys, orig
+}
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case string, int, int64, uint64, float64, bool:
+		return true
+	}
+	return false
+}
+
+// checkField checks a single value against its schema
+func checkField(path string, f field, v interface{}) []ValidationError {
+	errs := make([]ValidationError, 0)
+	switch f.kind {
+	case kindString:
+		s, ok := v.(string)
+		if !ok {
+			return append(errs, ValidationError{path, "must be " + f.kind.String()})
+		}
+		if f.maxLength > 0 && len(s) > f.maxLength {
+			errs = append(errs, ValidationError{path, fmt.Sprintf("must be no more than %v characters", f.maxLength)})
+		}
+		if f.values != nil && !contains(f.values, strings.ToLower(s)) {
+			errs = append(errs, ValidationError{path, fmt.Sprintf("must be one of %v", strings.Join(f.values, ", "))})
+		}
+		if f.objectName && s != "" && !mqsc.ValidName(s) {
+			errs = append(errs, ValidationError{path, "must only contain the characters A-Z, a-z, 0-9, '.', '_', '/' and '%'"})
+		}
+		if f.required && s == "" {
+			errs = append(errs, ValidationError{path, "must not be empty"})
+		}
+	case kindInt:
+		i, ok := v.(int)
+		if !ok {
+			return append(errs, ValidationError{path, "must be " + f.kind.String()})
+		}
+		if i < f.min || i > f.max {
+			errs = append(errs, ValidationError{path, fmt.Sprintf("must be between %v and %v", f.min, f.max)})
+		}
+	case kindBool:
+		if _, ok := v.(bool); !ok {
+			errs = append(errs, ValidationError{path, "must be " + f.kind.String()})
+		}
+	case kindStringList:
+		l, ok := v.([]interface{})
+		if !ok {
+			return append(errs, ValidationError{path, "must be " + f.kind.String()})
+		}
+		for i, item := range l {
+			s, ok := item.(string)
+			if !ok {
+				errs = append(errs, ValidationError{fmt.Sprintf("%v[%v]", path, i), "must be a string"})
+			} else if f.values != nil && !contains(f.values, strings.ToLower(s)) {
+				errs = append(errs, ValidationError{fmt.Sprintf("%v[%v]", path, i), fmt.Sprintf("must be one of %v", strings.Join(f.values, ", "))})
+			}
+		}
+		if f.required && len(l) == 0 {
+			errs = append(errs, ValidationError{path, "must not be empty"})
+		}
+	case kindStringMap:
+		m, ok := v.(map[interface{}]interface{})
+		if !ok {
+			return append(errs, ValidationError{path, "must be " + f.kind.String()})
+		}
+		keys, orig := sortedKeys(m)
+		for _, k := range keys {
+			if !isScalar(m[orig[k]]) {
+				errs = append(errs, ValidationError{path + "." + k, "must be a single value"})
+			}
+		}
+	}
+	return errs
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks a parsed YAML document against the schema
+func validate(doc interface{}) error {
+	if doc == nil {
+		return nil
+	}
+	errs := make(ValidationErrors, 0)
+	top, ok := doc.(map[interface{}]interface{})
+	if !ok {
+		return ValidationErrors{{"", "configuration must be a map"}}
+	}
+	keys, orig := sortedKeys(top)
+	for _, section := range keys {
+		s, ok := sections[section]
+		if !ok {
+			errs = append(errs, ValidationError{section, "unknown section"})
+			continue
+		}
+		value := top[orig[section]]
+		if value == nil {
+			continue
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			errs = append(errs, ValidationError{section, "must be a list"})
+			continue
+		}
+		for i, item := range items {
+			path := fmt.Sprintf("%v[%v]", section, i)
+			m, ok := item.(map[interface{}]interface{})
+			if !ok {
+				errs = append(errs, ValidationError{path, "must be a map"})
+				continue
+			}
+			fields, orig := sortedKeys(m)
+			for _, name := range fields {
+				f, ok := s[name]
+				if !ok {
+					errs = append(errs, ValidationError{path + "." + name, "unknown field"})
+					continue
+				}
+				errs = append(errs, checkField(path+"."+name, f, m[orig[name]])...)
+			}
+			names := make([]string, 0, len(s))
+			for name := range s {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if _, ok := orig[name]; s[name].required && !ok {
+					errs = append(errs, ValidationError{path + "." + name, "is required"})
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validate checks the rules which depend on more than one field
+func (c *Config) validate() error {
+	errs := make(ValidationErrors, 0)
+	seen := make(map[string]string)
+	for i, q := range c.Queues {
+		path := fmt.Sprintf("queues[%v]", i)
+		// All types of queue share the same namespace
+		if prev, ok := seen[q.Name]; ok {
+			errs = append(errs, ValidationError{path + ".name", fmt.Sprintf("duplicate name %v, also used by %v", q.Name, prev)})
+		}
+		seen[q.Name] = path
+		t := strings.ToLower(q.Type)
+		if t == "alias" && q.Target == "" {
+			errs = append(errs, ValidationError{path + ".target", "is required for an alias queue"})
+		}
+		if t != "alias" && q.Target != "" {
+			errs = append(errs, ValidationError{path + ".target", "is only valid for an alias queue"})
+		}
+		if t != "remote" && (q.RemoteName != "" || q.RemoteQueueManager != "" || q.TransmissionQueue != "") {
+			errs = append(errs, ValidationError{path, "remoteName, remoteQueueManager and transmissionQueue are only valid for a remote queue"})
+		}
+		if (t == "alias" || t == "remote") && (q.MaxDepth != nil || q.Usage != "") {
+			errs = append(errs, ValidationError{path, "maxDepth and usage are only valid for a local or model queue"})
+		}
+	}
+	errs = append(errs, reserved("queues", len(c.Queues), func(i int) map[string]string { return c.Queues[i].Attributes })...)
+	errs = append(errs, reserved("topics", len(c.Topics), func(i int) map[string]string { return c.Topics[i].Attributes })...)
+	errs = append(errs, reserved("channels", len(c.Channels), func(i int) map[string]string { return c.Channels[i].Attributes })...)
+	errs = append(errs, reserved("listeners", len(c.Listeners), func(i int) map[string]string { return c.Listeners[i].Attributes })...)
+	errs = append(errs, duplicates("topics", len(c.Topics), func(i int) string { return c.Topics[i].Name })...)
+	errs = append(errs, duplicates("channels", len(c.Channels), func(i int) string { return c.Channels[i].Name })...)
+	errs = append(errs, duplicates("listeners", len(c.Listeners), func(i int) string { return c.Listeners[i].Name })...)
+	for i, ch := range c.Channels {
+		path := fmt.Sprintf("channels[%v]", i)
+		switch strings.ToLower(ch.Type) {
+		case "sdr":
+			if ch.TransmissionQueue == "" {
+				errs = append(errs, ValidationError{path + ".transmissionQueue", "is required for a sender channel"})
+			}
+			fallthrough
+		case "clntconn", "clussdr":
+			if ch.ConnectionName == "" {
+				errs = append(errs, ValidationError{path + ".connectionName", "is required for a " + strings.ToLower(ch.Type) + " channel"})
+			}
+		}
+	}
+	for i, r := range c.ChannelAuth {
+		path := fmt.Sprintf("channelAuth[%v]", i)
+		var required string
+		var missing bool
+		switch strings.ToLower(r.Type) {
+		case "blockuser":
+			required, missing = "userList", len(r.UserList) == 0
+		case "blockaddr":
+			required, missing = "addressList", len(r.AddressList) == 0
+			if r.Channel != "*" {
+				errs = append(errs, ValidationError{path + ".channel", "must be * for a blockaddr rule"})
+			}
+		case "addressmap":
+			required, missing = "address", r.Address == ""
+		case "usermap":
+			required, missing = "clientUser", r.ClientUser == ""
+		case "sslpeermap":
+			required, missing = "sslPeer", r.SSLPeer == ""
+		case "qmgrmap":
+			required, missing = "queueManager", r.QueueManager == ""
+		}
+		if missing {
+			errs = append(errs, ValidationError{path + "." + required, "is required for a " + strings.ToLower(r.Type) + " rule"})
+		}
+	}
+	for i, a := range c.Authorities {
+		path := fmt.Sprintf("authorities[%v]", i)
+		if (a.Principal == "") == (a.Group == "") {
+			errs = append(errs, ValidationError{path, "exactly one of principal or group is required"})
+		}
+		if a.Profile == "" && strings.ToLower(a.ObjectType) != "qmgr" {
+			errs = append(errs, ValidationError{path + ".profile", "is required for object type " + a.ObjectType})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// duplicates checks for duplicate names in a section
+func duplicates(section string, n int, name func(int) string) []ValidationError {
+	errs := make([]ValidationError, 0)
+	seen := make(map[string]string)
+	for i := 0; i < n; i++ {
+		path := fmt.Sprintf("%v[%v]", section, i)
+		if prev, ok := seen[name(i)]; ok {
+			errs = append(errs, ValidationError{path + ".name", fmt.Sprintf("duplicate name %v, also used by %v", name(i), prev)})
+		}
+		seen[name(i)] = path
+	}
+	return errs
+}
+
+// reserved checks that the CUSTOM attribute, which is used to label the
+// objects defined from the configuration, isn't set in a section
+func reserved(section string, n int, attributes func(int) map[string]string) []ValidationError {
+	errs := make([]ValidationError, 0)
+	for i := 0; i < n; i++ {
+		for name := range attributes(i) {
+			if strings.ToUpper(name) == "CUSTOM" {
+				errs = append(errs, ValidationError{fmt.Sprintf("%v[%v].attributes.%v", section, i, name), "is reserved"})
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,78 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package templates contains the helpers shared by the Go text templates used
+// for MQSC files and queue manager names
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Environ returns the environment variables in environ (as returned by
+// os.Environ) as a map, for use as template data
+func Environ(environ []string) map[string]string {
+	env := make(map[string]string)
+	for _, e := range environ {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+	return env
+}
+
+// Funcs returns the helper functions available to templates, which use
+// getenv to look up environment variables
+func Funcs(getenv func(string) string) template.FuncMap {
+	return template.FuncMap{
+		// env returns the value of an environment variable, or an empty string
+		"env": getenv,
+		// default returns the value, or def if the value is empty, for example
+		// {{ env "APP_QUEUE" | default "APP.QUEUE" }}
+		"default": func(def string, value string) string {
+			if value == "" {
+				return def
+			}
+			return value
+		},
+		// required returns the value of an environment variable, or an error
+		// if it isn't set, for example {{ required "APP_QUEUE" }}
+		"required": func(name string) (string, error) {
+			value := getenv(name)
+			if value == "" {
+				return "", fmt.Errorf("required environment variable %v is not set", name)
+			}
+			return value, nil
+		},
+		// replace replaces every instance of old with new, for example
+		// {{ .Hostname | replace "-" "_" }}
+		"replace": func(old string, new string, s string) string {
+			return strings.Replace(s, old, new, -1)
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}
+}
+
+// New returns a new template with the helper functions.  Missing map keys,
+// such as unset environment variables in {{ .Env.NAME }}, are rendered as
+// empty strings.
+func New(name string, getenv func(string) string) *template.Template {
+	return template.New(name).Funcs(Funcs(getenv)).Option("missingkey=zero")
+}
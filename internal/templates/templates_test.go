@@ -0,0 +1,70 @@
+/*
+© Copyright IBM Corporation 2017
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package templates
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEnviron(t *testing.T) {
+	env := Environ([]string{"A=1", "B=x=y", "C=", "D"})
+	expected := map[string]string{"A": "1", "B": "x=y", "C": ""}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("Environ() - expected %v, got %v", expected, env)
+	}
+}
+
+var renderTests = []struct {
+	text string
+	out  string
+	err  bool
+}{
+	{`{{ env "APP" }}`, "app1", false},
+	{`{{ env "MISSING" | default "def" }}`, "def", false},
+	{`{{ env "APP" | default "def" | upper }}`, "APP1", false},
+	{`{{ "A-B" | replace "-" "_" | lower }}`, "a_b", false},
+	{`{{ required "APP" }}`, "app1", false},
+	{`{{ .Env.MISSING }}`, "", false},
+	{`{{ required "MISSING" }}`, "", true},
+}
+
+func TestNew(t *testing.T) {
+	env := map[string]string{"APP": "app1"}
+	getenv := func(name string) string {
+		return env[name]
+	}
+	data := struct{ Env map[string]string }{env}
+	for _, table := range renderTests {
+		tmpl, err := New("test", getenv).Parse(table.text)
+		if err != nil {
+			t.Errorf("Parse(%v) - unexpected error: %v", table.text, err)
+			continue
+		}
+		var buf bytes.Buffer
+		err = tmpl.Execute(&buf, data)
+		if table.err {
+			if err == nil {
+				t.Errorf("Execute(%v) - expected error, got %v", table.text, buf.String())
+			}
+			continue
+		}
+		if err != nil || buf.String() != table.out {
+			t.Errorf("Execute(%v) - expected %v, got %v, %v", table.text, table.out, buf.String(), err)
+		}
+	}
+}
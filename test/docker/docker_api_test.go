@@ -140,7 +140,24 @@ func TestNoQueueManagerName(t *testing.T) {
 
 func TestNoQueueManagerNameInvalidHostname(t *testing.T) {
 	t.Parallel()
-	utilTestNoQueueManagerName(t, "test-1", "test1")
+	utilTestNoQueueManagerName(t, "test-1", "test_1")
+}
+
+func TestInvalidQueueManagerName(t *testing.T) {
+	t.Parallel()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	containerConfig := container.Config{
+		Env: []string{"LICENSE=accept", "MQ_QMGR_NAME=qm-1"},
+	}
+	id := runContainer(t, cli, &containerConfig)
+	defer cleanContainer(t, cli, id)
+	rc := waitForContainer(t, cli, id, 10)
+	if rc != 1 {
+		t.Errorf("Expected rc=1, got rc=%v", rc)
+	}
 }
 
 // TestWithVolume runs a container with a Docker volume, then removes that
@@ -361,3 +378,57 @@ func TestMQSC(t *testing.T) {
 		t.Fatalf("Expected runmqsc to exit with rc=0, got %v", rc)
 	}
 }
+
+// TestMQSCError creates a new image with an invalid MQSC file in, and checks
+// that the container fails to start
+func TestMQSCError(t *testing.T) {
+	t.Parallel()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files = []struct {
+		Name, Body string
+	}{
+		{"Dockerfile", fmt.Sprintf("FROM %v\nADD test.mqsc /etc/mqm/", imageName())},
+		{"test.mqsc", "DEFINE QLOCAL(test)\nDEFINE QLOCL(test2)"},
+	}
+	tag := createImage(t, cli, files)
+	defer deleteImage(t, cli, tag)
+
+	containerConfig := container.Config{
+		Env:   []string{"LICENSE=accept", "MQ_QMGR_NAME=qm1"},
+		Image: tag,
+	}
+	id := runContainer(t, cli, &containerConfig)
+	defer cleanContainer(t, cli, id)
+	rc := waitForContainer(t, cli, id, 60)
+	if rc != 1 {
+		t.Errorf("Expected rc=1, got rc=%v", rc)
+	}
+}
+
+// TestEndMQM starts a queue manager, then ends it using `endmqm`, without
+// stopping the container.  runmqserver should detect that the queue manager
+// has ended, and exit with an error.
+func TestEndMQM(t *testing.T) {
+	t.Parallel()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	containerConfig := container.Config{
+		Env: []string{"LICENSE=accept", "MQ_QMGR_NAME=qm1"},
+	}
+	id := runContainer(t, cli, &containerConfig)
+	defer cleanContainer(t, cli, id)
+	waitForReady(t, cli, id)
+	rc := execContainerWithExitCode(t, cli, id, "mqm", []string{"endmqm", "-i", "qm1"})
+	if rc != 0 {
+		t.Fatalf("Expected endmqm to exit with rc=0, got %v", rc)
+	}
+	rc = waitForContainer(t, cli, id, 30)
+	if rc != 1 {
+		t.Errorf("Expected rc=1, got rc=%v", rc)
+	}
+}